@@ -37,6 +37,19 @@ func BenchmarkLogServer(b *testing.B) {
 	}
 }
 
+// 服务器模式下写入日志文件的性能，计时包括日志缓冲通道监听将日志写入日志文件的时间
+func BenchmarkLogServerFile(b *testing.B) {
+	setupServerFileOnce.Do(setupServerFile)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		serverFileLogger.Debugf("测试写入日志: %d", i+1)
+	}
+	if err := serverFileLogger.Sync(); err != nil {
+		b.Fatal(err)
+	}
+}
+
 func BenchmarkLogLocal(b *testing.B) {
 	setupLocalOnce.Do(setupLocal)
 	b.ReportAllocs()
@@ -77,6 +90,30 @@ func setupServer() {
 	zlog.Debug("准备测试日志文件")
 }
 
+var setupServerFileOnce sync.Once
+
+var serverFileLogger *zlog.Logger
+
+func setupServerFile() {
+	err := zlog.ClearDir("testdata")
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverFileLogger, err = zlog.NewLogger(&zlog.Config{
+		LogForbidStdout:   true,
+		LogFileDir:        "testdata",
+		LogFileNamePrefix: "serverfile",
+		LogMod:            zlog.LOG_MODE_SERVER,
+		LogLevelGlobal:    zlog.LOG_LEVEL_DEBUG,
+		LogChnOverPolicy:  zlog.LOG_CHN_OVER_POLICY_BLOCK,
+		LogFileMaxSizeM:   100,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverFileLogger.Debug("准备测试日志文件")
+}
+
 var setupLocalOnce sync.Once
 
 func setupLocal() {
@@ -15,365 +15,381 @@ zclog/api.go 提供zcgolog日志输出接口函数
 */
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"strings"
 )
 
+// 生成调用栈日志内容
+//
+//	skip 与在调用方函数中直接调用runtime.Callers时的skip含义相同
+func callerStackMsg(skip int, headMsg string) string {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	var msgBuilder strings.Builder
+	if headMsg != "" {
+		msgBuilder.WriteString(headMsg)
+		msgBuilder.WriteString("\n")
+	}
+	msgBuilder.WriteString("当前调用栈如下:\n")
+	for {
+		frame, more := frames.Next()
+		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
+		if !more {
+			break
+		}
+	}
+	return msgBuilder.String()
+}
+
 // Print 日志级别: DEBUG
 func Print(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Printf 日志级别: DEBUG
 func Printf(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Println 日志级别: DEBUG
 func Println(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // PrintWithCallerDepth 日志级别: DEBUG
 func PrintWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // PrintfWithCallerDepth 日志级别: DEBUG
 func PrintfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // PrintlnWithCallerDepth 日志级别: DEBUG
 func PrintlnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // Debug 输出Debug日志
 func Debug(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Debugf 输出Debug日志
 func Debugf(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Debugln 输出Debug日志
 func Debugln(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // DebugStack 输出调用栈(DEBUG)
 func DebugStack(headMsg string) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	var pcs [32]uintptr
-	n := runtime.Callers(2, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
 }
 
 // DebugWithCallerDepth 输出Debug日志
 func DebugWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // DebugfWithCallerDepth 输出Debug日志
 func DebugfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // DebuglnWithCallerDepth 输出Debug日志
 func DebuglnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // DebugStackWithCallerDepth 输出调用栈(DEBUG)
 func DebugStackWithCallerDepth(callerDepth int, headMsg string) {
 	msgLogLevel := LOG_LEVEL_DEBUG
-	var pcs [32]uintptr
-	n := runtime.Callers(callerDepth, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Debugw 输出Debug日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func Debugw(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// DebugwWithCallerDepth 输出Debug日志，附带结构化字段
+func DebugwWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// DebugCtx 输出Debug日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func DebugCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	FromContext(ctx).outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// DebugfCtx 输出Debug日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func DebugfCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	FromContext(ctx).outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
 }
 
 // Info 输出Info日志
 func Info(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_INFO
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Infof 输出Info日志
 func Infof(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_INFO
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Infoln 输出Info日志
 func Infoln(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_INFO
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // InfoStack 输出调用栈(INFO)
 func InfoStack(headMsg string) {
 	msgLogLevel := LOG_LEVEL_INFO
-	var pcs [32]uintptr
-	n := runtime.Callers(2, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
 }
 
 // InfoWithCallerDepth 输出Info日志
 func InfoWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_INFO
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // InfofWithCallerDepth 输出Info日志
 func InfofWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_INFO
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // InfolnWithCallerDepth 输出Info日志
 func InfolnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_INFO
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // InfoStackWithCallerDepth 输出调用栈(INFO)
 func InfoStackWithCallerDepth(callerDepth int, headMsg string) {
 	msgLogLevel := LOG_LEVEL_INFO
-	var pcs [32]uintptr
-	n := runtime.Callers(callerDepth, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Infow 输出Info日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func Infow(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// InfowWithCallerDepth 输出Info日志，附带结构化字段
+func InfowWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// InfoCtx 输出Info日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func InfoCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	FromContext(ctx).outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// InfofCtx 输出Info日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func InfofCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	FromContext(ctx).outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
 }
 
 // Warn 输出Warn日志
 func Warn(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Warnf 输出Warn日志
 func Warnf(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Warnln 输出Warn日志
 func Warnln(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // WarnStack 输出调用栈(WARNING)
 func WarnStack(headMsg string) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	var pcs [32]uintptr
-	n := runtime.Callers(2, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
 }
 
 // WarnWithCallerDepth 输出Warn日志
 func WarnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // WarnfWithCallerDepth 输出Warn日志
 func WarnfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // WarnlnWithCallerDepth 输出Warn日志
 func WarnlnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // WarnStackWithCallerDepth 输出调用栈(WARNING)
 func WarnStackWithCallerDepth(callerDepth int, headMsg string) {
 	msgLogLevel := LOG_LEVEL_WARNING
-	var pcs [32]uintptr
-	n := runtime.Callers(callerDepth, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Warnw 输出Warn日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func Warnw(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// WarnwWithCallerDepth 输出Warn日志，附带结构化字段
+func WarnwWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// WarnCtx 输出Warn日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func WarnCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	FromContext(ctx).outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// WarnfCtx 输出Warn日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func WarnfCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	FromContext(ctx).outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
 }
 
 // Error 输出Error日志
 func Error(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Errorf 输出Error日志
 func Errorf(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Errorln 输出Error日志
 func Errorln(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // ErrorStack 输出调用栈(ERROR)
 func ErrorStack(headMsg string) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	var pcs [32]uintptr
-	n := runtime.Callers(2, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
 }
 
 // ErrorWithCallerDepth 输出Error日志
 func ErrorWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // ErrorfWithCallerDepth 输出Error日志
 func ErrorfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // ErrorlnWithCallerDepth 输出Error日志
 func ErrorlnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // ErrorStackWithCallerDepth 输出调用栈(ERROR)
 func ErrorStackWithCallerDepth(callerDepth int, headMsg string) {
 	msgLogLevel := LOG_LEVEL_ERROR
-	var pcs [32]uintptr
-	n := runtime.Callers(callerDepth, pcs[:]) // skip first 3 frames
-	frames := runtime.CallersFrames(pcs[:n])
-	var msgBuilder strings.Builder
-	if headMsg != "" {
-		msgBuilder.WriteString(headMsg)
-		msgBuilder.WriteString("\n")
-	}
-	msgBuilder.WriteString("当前调用栈如下:\n")
-	for {
-		frame, more := frames.Next()
-		msgBuilder.WriteString(fmt.Sprintf("%s:%d %s\n", frame.File, frame.Line, frame.Function))
-		if !more {
-			break
-		}
-	}
-	outputLog(msgLogLevel, msgBuilder.String(), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Errorw 输出Error日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func Errorw(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// ErrorwWithCallerDepth 输出Error日志，附带结构化字段
+func ErrorwWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	defaultLogger.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// ErrorCtx 输出Error日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func ErrorCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	FromContext(ctx).outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// ErrorfCtx 输出Error日志，附带上下文字段
+//
+//	ctx中存有Logger时使用该Logger输出，否则使用默认Logger
+func ErrorfCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	FromContext(ctx).outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
 }
 
 // Panic 直接输出日志，终止当前goroutine
@@ -381,7 +397,7 @@ func ErrorStackWithCallerDepth(callerDepth int, headMsg string) {
 //goland:noinspection GoUnusedExportedFunction
 func Panic(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_PANIC
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Panicf 直接输出日志，终止当前goroutine
@@ -389,7 +405,7 @@ func Panic(v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func Panicf(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_PANIC
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Panicln 直接输出日志，终止当前goroutine
@@ -397,7 +413,7 @@ func Panicf(msg string, params ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func Panicln(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_PANIC
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // PanicWithCallerDepth 直接输出日志，终止当前goroutine
@@ -405,7 +421,7 @@ func Panicln(v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func PanicWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_PANIC
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // PanicfWithCallerDepth 直接输出日志，终止当前goroutine
@@ -413,7 +429,7 @@ func PanicWithCallerDepth(callerDepth int, v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func PanicfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_PANIC
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // PaniclnWithCallerDepth 直接输出日志，终止当前goroutine
@@ -421,7 +437,7 @@ func PanicfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func PaniclnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_PANIC
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // Fatal 直接输出日志，终止程序
@@ -429,7 +445,7 @@ func PaniclnWithCallerDepth(callerDepth int, v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func Fatal(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_FATAL
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // Fatalf 直接输出日志，终止程序
@@ -437,7 +453,7 @@ func Fatal(v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func Fatalf(msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_FATAL
-	outputLog(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
 }
 
 // Fatalln 直接输出日志，终止程序
@@ -445,7 +461,7 @@ func Fatalf(msg string, params ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func Fatalln(v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_FATAL
-	outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
 }
 
 // FatalWithCallerDepth 直接输出日志，终止程序
@@ -453,7 +469,7 @@ func Fatalln(v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func FatalWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_FATAL
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
 
 // FatalfWithCallerDepth 直接输出日志，终止程序
@@ -461,7 +477,7 @@ func FatalWithCallerDepth(callerDepth int, v ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func FatalfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 	msgLogLevel := LOG_LEVEL_FATAL
-	outputLog(msgLogLevel, msg, callerDepth, params...)
+	defaultLogger.outputLogf(msgLogLevel, msg, callerDepth, params...)
 }
 
 // FatallnWithCallerDepth 直接输出日志，终止程序
@@ -469,5 +485,5 @@ func FatalfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
 //goland:noinspection GoUnusedExportedFunction
 func FatallnWithCallerDepth(callerDepth int, v ...interface{}) {
 	msgLogLevel := LOG_LEVEL_FATAL
-	outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+	defaultLogger.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
 }
@@ -26,40 +26,40 @@ func New(out io.Writer, prefix string, flag int) *log.Logger {
 
 //goland:noinspection GoUnusedExportedFunction
 func Default() *log.Logger {
-	return zcgoLogger
+	return defaultLogger.stdLogger
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func SetOutput(w io.Writer) {
-	zcgoLogger.SetOutput(w)
+	defaultLogger.stdLogger.SetOutput(w)
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func Flags() int {
-	return zcgoLogger.Flags()
+	return defaultLogger.stdLogger.Flags()
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func SetFlags(flag int) {
-	zcgoLogger.SetFlags(flag)
+	defaultLogger.stdLogger.SetFlags(flag)
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func Prefix() string {
-	return zcgoLogger.Prefix()
+	return defaultLogger.stdLogger.Prefix()
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func SetPrefix(prefix string) {
-	zcgoLogger.SetPrefix(prefix)
+	defaultLogger.stdLogger.SetPrefix(prefix)
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func Writer() io.Writer {
-	return zcgoLogger.Writer()
+	return defaultLogger.stdLogger.Writer()
 }
 
 //goland:noinspection GoUnusedExportedFunction
 func Output(calldepth int, s string) error {
-	return zcgoLogger.Output(calldepth+1, s) // +1 for this frame.
+	return defaultLogger.stdLogger.Output(calldepth+1, s) // +1 for this frame.
 }
@@ -15,10 +15,12 @@ zclog/log.go zcgolog基本处理，包括配置相关定义与处理、本地模
 */
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,7 +28,7 @@ import (
 //
 //goland:noinspection GoSnakeCaseUsage
 const (
-	// LOG_MODE_LOCAL 本地模式: 日志同步输出且不支持在线修改指定logger的日志级别，日志文件不支持自动滚动，通常仅用于测试
+	// LOG_MODE_LOCAL 本地模式: 日志同步输出且不支持在线修改指定logger的日志级别，日志文件与服务器模式一样支持自动滚动，适用于测试、命令行工具与批处理任务
 	LOG_MODE_LOCAL = iota + 1
 	// LOG_MODE_SERVER 服务器模式: 日志异步输出且支持在线修改指定logger的日志级别，日志文件支持自动滚动
 	LOG_MODE_SERVER
@@ -41,12 +43,50 @@ type Config struct {
 	LogFileDir string `json:"log_file_dir" yaml:"log_file_dir" mapstructure:"log_file_dir"`
 	// 日志文件名前缀，默认: zcgolog
 	LogFileNamePrefix string `json:"log_file_name_prefix" yaml:"log_file_name_prefix" mapstructure:"log_file_name_prefix"`
+	// 日志文件命名模板，默认: "%prefix_%date_%seq"，支持的占位符参考 LOG_FILE_NAME_PATTERN_DEFAULT 相关定义
+	LogFileNamePattern string `json:"log_file_name_pattern" yaml:"log_file_name_pattern" mapstructure:"log_file_name_pattern"`
+	// 日志文件名中滚动周期标识的日期格式，采用go时间格式，默认按小时滚动时为"2006010215"，其他为"20060102"
+	LogFileDateLayout string `json:"log_file_date_layout" yaml:"log_file_date_layout" mapstructure:"log_file_date_layout"`
+	// 日志文件名中序列号的宽度，不足时补0，默认: 5
+	LogFileSeqWidth int `json:"log_file_seq_width" yaml:"log_file_seq_width" mapstructure:"log_file_seq_width"`
+	// 日志文件扩展名，默认: ".log"
+	LogFileExt string `json:"log_file_ext" yaml:"log_file_ext" mapstructure:"log_file_ext"`
+	// 日志文件权限，默认: 0644
+	LogFileMode os.FileMode `json:"log_file_mode" yaml:"log_file_mode" mapstructure:"log_file_mode"`
+	// 日志目录权限，仅在zclog创建日志目录时使用，默认: 0755
+	LogDirMode os.FileMode `json:"log_dir_mode" yaml:"log_dir_mode" mapstructure:"log_dir_mode"`
+	// 日志文件与日志目录的属组，可以是组名或gid，默认: 空，表示不修改属组
+	LogFileGroup string `json:"log_file_group" yaml:"log_file_group" mapstructure:"log_file_group"`
+	// 额外输出到错误日志文件 [LogFileNamePrefix]_error 的最低日志级别，默认: 0，表示不输出错误日志文件
+	LogErrorFileLevel int `json:"log_error_file_level" yaml:"log_error_file_level" mapstructure:"log_error_file_level"`
 	// 日志文件大小上限，单位M，默认: 2
 	LogFileMaxSizeM int `json:"log_file_max_size_m" yaml:"log_file_max_size_m" mapstructure:"log_file_max_size_m"`
+	// 日志文件按时间滚动的周期，默认: LOG_ROTATE_DAILY 按天滚动; LOG_ROTATE_HOURLY 按小时滚动; LOG_ROTATE_WEEKLY 按周滚动
+	LogFileRotateInterval int `json:"log_file_rotate_interval" yaml:"log_file_rotate_interval" mapstructure:"log_file_rotate_interval"`
+	// 日志文件按时间滚动的时间偏移，单位分钟，默认: 0。如按天滚动时配置为120，则每天02:00滚动
+	LogFileRotateOffsetMinutes int `json:"log_file_rotate_offset_minutes" yaml:"log_file_rotate_offset_minutes" mapstructure:"log_file_rotate_offset_minutes"`
+	// 是否在日志目录下维护指向当前日志文件的软链接 [LogFileDir]/[LogFileNamePrefix].log，默认: false
+	LogFileSymlink bool `json:"log_file_symlink" yaml:"log_file_symlink" mapstructure:"log_file_symlink"`
+	// 是否在收到SIGHUP信号时重新打开日志文件，默认: false
+	LogReopenOnSIGHUP bool `json:"log_reopen_on_sighup" yaml:"log_reopen_on_sighup" mapstructure:"log_reopen_on_sighup"`
+	// 服务器模式下日志文件写入缓冲区大小，单位K，默认: 256
+	LogFileBufferSizeK int `json:"log_file_buffer_size_k" yaml:"log_file_buffer_size_k" mapstructure:"log_file_buffer_size_k"`
+	// 服务器模式下日志文件写入缓冲区的刷新间隔，单位毫秒，默认: 1000
+	LogFileFlushIntervalMilliSec int `json:"log_file_flush_interval_milli_sec" yaml:"log_file_flush_interval_milli_sec" mapstructure:"log_file_flush_interval_milli_sec"`
+	// 滚动后的日志文件压缩方式，默认为空，表示不压缩; LOG_COMPRESSION_GZIP 使用gzip压缩; 也可以是通过 RegisterCompressor 注册的压缩方式
+	LogFileCompression string `json:"log_file_compression" yaml:"log_file_compression" mapstructure:"log_file_compression"`
+	// 历史日志文件最长保留天数，默认: 0，表示不按天数清理
+	LogFileMaxAgeDays int `json:"log_file_max_age_days" yaml:"log_file_max_age_days" mapstructure:"log_file_max_age_days"`
+	// 最多保留的日志文件数量，默认: 0，表示不按数量清理
+	LogFileMaxCount int `json:"log_file_max_count" yaml:"log_file_max_count" mapstructure:"log_file_max_count"`
+	// 日志文件总大小上限，单位M，默认: 0，表示不按总大小清理
+	LogFileMaxTotalSizeM int `json:"log_file_max_total_size_m" yaml:"log_file_max_total_size_m" mapstructure:"log_file_max_total_size_m"`
 	// 全局日志级别，默认:INFO
 	LogLevelGlobal int `json:"log_level_global" yaml:"log_level_global" mapstructure:"log_level_global"`
-	// 日志格式，默认: "%datetime %level %file %line %func %msg"，目前格式固定，该配置暂时没有使用
+	// 日志行格式，默认: "%level 时间:%pushTime 代码:%file %line 函数:%callFunc %msg"，支持的占位符参考 CheckLogLineFormat
 	LogLineFormat string `json:"log_line_format" yaml:"log_line_format" mapstructure:"log_line_format"`
+	// 日志输出编码，默认: LOG_ENCODER_TEXT 按LogLineFormat输出文本; LOG_ENCODER_JSON 每条日志输出为一行JSON
+	LogEncoder int `json:"log_encoder" yaml:"log_encoder" mapstructure:"log_encoder"`
 	// 日志模式，默认采用本地模式，以便于本地测试
 	LogMod int `json:"log_mod" yaml:"log_mod" mapstructure:"log_mod"`
 	// 日志缓冲通道容量，默认 4096
@@ -57,151 +97,426 @@ type Config struct {
 	LogLevelCtlHost string `json:"log_level_ctl_host" yaml:"log_level_ctl_host" mapstructure:"log_level_ctl_host"`
 	// 日志级别控制监听服务的Port，默认:9300
 	LogLevelCtlPort string `json:"log_level_ctl_port" yaml:"log_level_ctl_port" mapstructure:"log_level_ctl_port"`
+	// 日志输出目标，默认: 空，此时按LogForbidStdout与LogFileDir输出到控制台与日志文件；配置后日志只输出到这些目标
+	LogSinks []SinkConfig `json:"log_sinks" yaml:"log_sinks" mapstructure:"log_sinks"`
 }
 
-// zcgoLogger
-var zcgoLogger = log.New(os.Stdout, "", log.Ldate|log.Ltime)
+// Logger zcgolog日志输出器
+//
+//	每个Logger拥有独立的配置、输出目标、日志缓冲通道、日志缓冲通道监听goroutine以及日志文件滚动状态，
+//	因此一个进程中可以同时存在多个不同配置的Logger，比如审计日志、访问日志与应用日志分别输出到不同目录。
+//	指定函数的日志级别控制(logLevelCtl)由所有Logger共享。
+//	包级别的日志输出函数(Debug,Info等)委托给默认Logger处理，默认Logger通过InitLogger配置。
+//	通过With派生的子Logger与父Logger共享logCore，只是额外绑定了一组结构化字段。
+type Logger struct {
+	*logCore
+	// 绑定的结构化字段，创建后不再修改，每条日志都会携带
+	fields []Field
+}
+
+// Logger的输出核心，包括配置、输出目标、日志缓冲通道以及日志文件滚动状态
+type logCore struct {
+	// 日志配置
+	config *Config
+	// 底层的log.Logger，负责添加写入时间前缀并写入输出目标
+	stdLogger *log.Logger
+	// 日志行格式模板
+	lineFormatter *lineFormatter
+	// 日志编码器
+	encoder logEncoder
+	// 日志行格式或日志输出目标的日志行格式是否使用了%goid，只有使用时才在调用方获取goroutine ID
+	needGoid bool
+	// 全局日志级别，日志输出时原子读取，可以通过SetLevel或日志级别控制服务在线修改
+	level atomic.Int64
+	// 是否是默认Logger，只有默认Logger会启动日志级别控制监听服务
+	isDefault bool
+
+	// 上锁,确保输出目标切换的线程安全
+	loggerLock sync.Mutex
+	// 日志文件输出，负责日志文件的滚动
+	fileWriter *logFileWriter
+	// SIGHUP信号通道，未监听SIGHUP信号时为nil
+	sighupChn chan os.Signal
+	// 已打开的日志输出目标，没有配置LogSinks或已关闭时为nil，此时日志由stdLogger输出
+	sinks atomic.Pointer[[]*logSink]
+	// 已打开的错误日志文件，没有配置LogErrorFileLevel或已关闭时为nil
+	errorFile atomic.Pointer[fileSink]
 
-// zcgolog配置
-var zcgologConfig = &Config{
-	LogForbidStdout:   false,
-	LogFileDir:        "",
-	LogFileNamePrefix: "zcgolog",
-	LogFileMaxSizeM:   2,
-	LogLevelGlobal:    LOG_LEVEL_INFO,
-	LogLineFormat:     "%level %pushTime %file %line %callFunc %msg",
-	LogChannelCap:     4096,
-	LogChnOverPolicy:  LOG_CHN_OVER_POLICY_DISCARD,
-	LogMod:            LOG_MODE_LOCAL,
-	LogLevelCtlHost:   "",
-	LogLevelCtlPort:   "9300",
+	// 日志缓冲通道
+	logMsgChn chan logMsg
+	// 退出通道,用于监听是否需要退出对日志缓冲通道的监听。
+	// 服务器模式下刷新日志配置重启服务器模式前，需要先通过该通道，通知当前对日志缓冲通道的监听服务退出。
+	quitChn chan int
+	// 通过排他锁控制同时只能有一个Goroutine执行readAndWriteMsg
+	msgReaderLock sync.Mutex
+	// 日志缓冲通道监听是否在运行
+	msgReaderRunning atomic.Bool
+	// 日志缓冲通道监听停止时关闭，每次启动监听时重新创建
+	msgReaderStopped chan struct{}
 }
 
-// 当前日志文件
-var currentLogFile *os.File
+// 默认Logger，包级别的日志输出函数都委托给它处理
+var defaultLogger = newLogger(newDefaultConfig(), true)
 
-// 关闭当前日志文件
-func closeCurrentLogFile() {
-	if currentLogFile != nil {
-		err := currentLogFile.Close()
-		if err != nil {
-			return
-		}
+// 生成默认配置
+func newDefaultConfig() *Config {
+	return &Config{
+		LogForbidStdout:              false,
+		LogFileDir:                   "",
+		LogFileNamePrefix:            "zcgolog",
+		LogFileNamePattern:           LOG_FILE_NAME_PATTERN_DEFAULT,
+		LogFileSeqWidth:              5,
+		LogFileExt:                   ".log",
+		LogFileMode:                  LOG_FILE_MODE_DEFAULT,
+		LogDirMode:                   LOG_DIR_MODE_DEFAULT,
+		LogFileMaxSizeM:              2,
+		LogFileRotateInterval:        LOG_ROTATE_DAILY,
+		LogFileBufferSizeK:           256,
+		LogFileFlushIntervalMilliSec: 1000,
+		LogLevelGlobal:               LOG_LEVEL_INFO,
+		LogLineFormat:                LOG_LINE_FORMAT_DEFAULT,
+		LogEncoder:                   LOG_ENCODER_TEXT,
+		LogChannelCap:                4096,
+		LogChnOverPolicy:             LOG_CHN_OVER_POLICY_DISCARD,
+		LogMod:                       LOG_MODE_LOCAL,
+		LogLevelCtlHost:              "",
+		LogLevelCtlPort:              "9300",
 	}
 }
 
-// 当天日期
-var currentLogYMD string
+// 创建一个尚未初始化输出目标的Logger
+func newLogger(config *Config, isDefault bool) *Logger {
+	formatter, _ := parseLineFormat(LOG_LINE_FORMAT_DEFAULT)
+	core := &logCore{
+		config:        config,
+		stdLogger:     log.New(os.Stdout, "", log.Ldate|log.Ltime),
+		lineFormatter: formatter,
+		encoder:       formatter,
+		isDefault:     isDefault,
+		fileWriter:    newLogFileWriter(config),
+		quitChn:       make(chan int),
+	}
+	core.level.Store(int64(config.LogLevelGlobal))
+	return &Logger{logCore: core}
+}
+
+// NewLogger 根据配置创建一个新的Logger
+//
+//	initConfig中未设置的配置项采用默认值，默认值与InitLogger相同；
+//	配置不合法(如日志行格式无法解析、服务器模式下日志目录为空)时返回错误。
+//	服务器模式下，新Logger使用自己的日志缓冲通道与监听goroutine，但不会启动日志级别控制监听服务，
+//	日志级别控制监听服务只随默认Logger启动，指定函数的日志级别控制对所有Logger生效。
+func NewLogger(initConfig *Config) (*Logger, error) {
+	config := newDefaultConfig()
+	mergeConfig(config, initConfig)
+	if _, err := CheckConfig(config); err != nil {
+		return nil, err
+	}
+	l := newLogger(config, false)
+	l.init()
+	return l, nil
+}
+
+// 关闭当前日志文件
+func (l *Logger) closeCurrentLogFile() {
+	_ = l.fileWriter.Close()
+}
 
 // InitLogger 初始化zcgolog
-func InitLogger(initConfig *Config) {
-	// 从参数中获取有效配置覆盖logConfig
-	if initConfig != nil {
-		if initConfig.LogForbidStdout {
-			zcgologConfig.LogForbidStdout = initConfig.LogForbidStdout
-		}
-		if initConfig.LogMod > 0 && initConfig.LogMod < log_mode_max {
-			zcgologConfig.LogMod = initConfig.LogMod
-		}
-		if initConfig.LogLevelCtlHost != "" {
-			zcgologConfig.LogLevelCtlHost = initConfig.LogLevelCtlHost
-		}
-		if initConfig.LogLevelCtlPort != "" {
-			zcgologConfig.LogLevelCtlPort = initConfig.LogLevelCtlPort
-		}
-		if initConfig.LogChannelCap > 0 {
-			zcgologConfig.LogChannelCap = initConfig.LogChannelCap
-		}
-		if initConfig.LogChnOverPolicy > 0 && initConfig.LogChnOverPolicy < log_chn_over_policy_max {
-			zcgologConfig.LogChnOverPolicy = initConfig.LogChnOverPolicy
-		}
-		if initConfig.LogFileDir != "" {
-			zcgologConfig.LogFileDir = initConfig.LogFileDir
-		}
-		if initConfig.LogFileMaxSizeM > 0 {
-			zcgologConfig.LogFileMaxSizeM = initConfig.LogFileMaxSizeM
-		}
-		if initConfig.LogFileNamePrefix != "" {
-			zcgologConfig.LogFileNamePrefix = initConfig.LogFileNamePrefix
-		}
-		if initConfig.LogLevelGlobal > 0 && initConfig.LogLevelGlobal < log_level_max {
-			zcgologConfig.LogLevelGlobal = initConfig.LogLevelGlobal
-		}
-		if initConfig.LogLineFormat != "" {
-			zcgologConfig.LogLineFormat = initConfig.LogLineFormat
+//
+//	配置默认Logger，即包级别日志输出函数使用的Logger。多次调用时，后一次的有效配置覆盖前一次的配置。
+//	日志行格式无法解析时返回错误，此时默认Logger保持原来的配置不变。
+func InitLogger(initConfig *Config) error {
+	// 修改默认Logger之前先检查日志行格式，与NewLogger一样不合法时返回错误
+	if initConfig != nil && initConfig.LogLineFormat != "" {
+		if err := CheckLogLineFormat(initConfig.LogLineFormat); err != nil {
+			return err
 		}
 	}
+	// 先停止日志缓冲通道监听，防止修改配置时与readAndWriteMsg对配置及日志行格式的读取发生冲突
+	if err := defaultLogger.QuitMsgReader(30000); err != nil {
+		return err
+	}
+	// 从参数中获取有效配置覆盖logConfig
+	mergeConfig(defaultLogger.config, initConfig)
+	defaultLogger.init()
+	return nil
+}
+
+// 将initConfig中的有效配置覆盖到config
+func mergeConfig(config *Config, initConfig *Config) {
+	if initConfig == nil {
+		return
+	}
+	if initConfig.LogForbidStdout {
+		config.LogForbidStdout = initConfig.LogForbidStdout
+	}
+	if initConfig.LogMod > 0 && initConfig.LogMod < log_mode_max {
+		config.LogMod = initConfig.LogMod
+	}
+	if initConfig.LogLevelCtlHost != "" {
+		config.LogLevelCtlHost = initConfig.LogLevelCtlHost
+	}
+	if initConfig.LogLevelCtlPort != "" {
+		config.LogLevelCtlPort = initConfig.LogLevelCtlPort
+	}
+	if initConfig.LogChannelCap > 0 {
+		config.LogChannelCap = initConfig.LogChannelCap
+	}
+	if initConfig.LogChnOverPolicy > 0 && initConfig.LogChnOverPolicy < log_chn_over_policy_max {
+		config.LogChnOverPolicy = initConfig.LogChnOverPolicy
+	}
+	if initConfig.LogFileDir != "" {
+		config.LogFileDir = initConfig.LogFileDir
+	}
+	if initConfig.LogFileMaxSizeM > 0 {
+		config.LogFileMaxSizeM = initConfig.LogFileMaxSizeM
+	}
+	if initConfig.LogFileRotateInterval > 0 && initConfig.LogFileRotateInterval < log_rotate_max {
+		config.LogFileRotateInterval = initConfig.LogFileRotateInterval
+	}
+	if initConfig.LogFileRotateOffsetMinutes > 0 {
+		config.LogFileRotateOffsetMinutes = initConfig.LogFileRotateOffsetMinutes
+	}
+	if initConfig.LogFileSymlink {
+		config.LogFileSymlink = initConfig.LogFileSymlink
+	}
+	if initConfig.LogReopenOnSIGHUP {
+		config.LogReopenOnSIGHUP = initConfig.LogReopenOnSIGHUP
+	}
+	if initConfig.LogFileBufferSizeK > 0 {
+		config.LogFileBufferSizeK = initConfig.LogFileBufferSizeK
+	}
+	if initConfig.LogFileFlushIntervalMilliSec > 0 {
+		config.LogFileFlushIntervalMilliSec = initConfig.LogFileFlushIntervalMilliSec
+	}
+	if initConfig.LogFileCompression != "" {
+		config.LogFileCompression = initConfig.LogFileCompression
+	}
+	if initConfig.LogFileMaxAgeDays > 0 {
+		config.LogFileMaxAgeDays = initConfig.LogFileMaxAgeDays
+	}
+	if initConfig.LogFileMaxCount > 0 {
+		config.LogFileMaxCount = initConfig.LogFileMaxCount
+	}
+	if initConfig.LogFileMaxTotalSizeM > 0 {
+		config.LogFileMaxTotalSizeM = initConfig.LogFileMaxTotalSizeM
+	}
+	if initConfig.LogFileNamePrefix != "" {
+		config.LogFileNamePrefix = initConfig.LogFileNamePrefix
+	}
+	if initConfig.LogFileNamePattern != "" {
+		config.LogFileNamePattern = initConfig.LogFileNamePattern
+	}
+	if initConfig.LogFileDateLayout != "" {
+		config.LogFileDateLayout = initConfig.LogFileDateLayout
+	}
+	if initConfig.LogFileSeqWidth > 0 {
+		config.LogFileSeqWidth = initConfig.LogFileSeqWidth
+	}
+	if initConfig.LogFileExt != "" {
+		config.LogFileExt = initConfig.LogFileExt
+	}
+	if initConfig.LogFileMode != 0 {
+		config.LogFileMode = initConfig.LogFileMode
+	}
+	if initConfig.LogDirMode != 0 {
+		config.LogDirMode = initConfig.LogDirMode
+	}
+	if initConfig.LogFileGroup != "" {
+		config.LogFileGroup = initConfig.LogFileGroup
+	}
+	if initConfig.LogErrorFileLevel > 0 && initConfig.LogErrorFileLevel < log_level_max {
+		config.LogErrorFileLevel = initConfig.LogErrorFileLevel
+	}
+	if len(initConfig.LogSinks) > 0 {
+		config.LogSinks = initConfig.LogSinks
+	}
+	if initConfig.LogLevelGlobal > 0 && initConfig.LogLevelGlobal < log_level_max {
+		config.LogLevelGlobal = initConfig.LogLevelGlobal
+	}
+	if initConfig.LogLineFormat != "" {
+		config.LogLineFormat = initConfig.LogLineFormat
+	}
+	if initConfig.LogEncoder > 0 && initConfig.LogEncoder < log_encoder_max {
+		config.LogEncoder = initConfig.LogEncoder
+	}
+}
+
+// 根据当前配置初始化Logger
+//
+//	日志行格式需要已经由InitLogger或NewLogger检查过。
+func (l *Logger) init() {
+	// 解析日志行格式
+	formatter, _ := parseLineFormat(l.config.LogLineFormat)
+	l.lineFormatter = formatter
+	l.needGoid = formatter.needGoid || sinkConfigsNeedGoid(l.config)
+	// 根据日志输出编码选择日志编码器
+	if l.config.LogEncoder == LOG_ENCODER_JSON {
+		l.encoder = jsonEncoder{}
+	} else {
+		l.encoder = l.lineFormatter
+	}
 	// 设置全局日志级别
-	Level = zcgologConfig.LogLevelGlobal
+	l.SetLevel(l.config.LogLevelGlobal)
 	// 根据日志模式决定是否启用日志缓冲队列与在线修改日志级别功能
-	switch zcgologConfig.LogMod {
+	switch l.config.LogMod {
 	case LOG_MODE_SERVER:
 		// 启动zcgolog服务器模式
-		startZcgologServer()
+		l.startZcgologServer()
 	case LOG_MODE_LOCAL:
 		// 初始化zcgoLogger
-		initZcgoLogger()
+		l.initZcgoLogger()
 	default:
 		panic("unhandled default case")
 	}
+	// 根据配置启动或停止SIGHUP信号监听
+	l.setupSighupHandler()
 }
 
 // 输出日志
-func outputLog(msgLogLevel int, msg string, callerDepth int, params ...interface{}) {
+func (l *Logger) outputLog(msgLogLevel int, msg string, callerDepth int) {
+	// 调用者深度，默认为2
+	if callerDepth == 0 {
+		callerDepth = CALLER_DEPTH_DEFAULT
+	}
+	// 多经过一层outputLogWithFields，调用者深度+1
+	l.outputLogWithFields(msgLogLevel, msg, callerDepth+1, nil, nil, false, nil)
+}
+
+// 输出格式化日志，msg作为格式化字符串处理
+func (l *Logger) outputLogf(msgLogLevel int, msg string, callerDepth int, params ...interface{}) {
+	// 调用者深度，默认为2
+	if callerDepth == 0 {
+		callerDepth = CALLER_DEPTH_DEFAULT
+	}
+	// 多经过一层outputLogWithFields，调用者深度+1
+	l.outputLogWithFields(msgLogLevel, msg, callerDepth+1, nil, nil, true, params)
+}
+
+// 输出带结构化字段的日志
+//
+//	ctx非nil时，从ctx中提取的字段会附加到日志消息中；formatted为true时msg作为格式化字符串处理
+func (l *Logger) outputLogWithFields(msgLogLevel int, msg string, callerDepth int, ctx context.Context, fields []Field, formatted bool, params []interface{}) {
 	// 调用者深度，默认为2
 	if callerDepth == 0 {
 		callerDepth = CALLER_DEPTH_DEFAULT
 	}
 	// 获取日志接口调用方的程序计数器，文件名以及行号
 	pc, file, line, _ := runtime.Caller(callerDepth)
-	// 调用处函数包路径
-	myFunc := runtime.FuncForPC(pc).Name()
-	// Panic与Fatal直接调用log包处理
-	if msgLogLevel == LOG_LEVEL_PANIC {
-		msgPrefix := fmt.Sprintf("%s 代码:%s %d 函数:%s ", LogLevels[msgLogLevel], file, line, myFunc)
-		// 输出panic日志并抛出panic，当前goroutine终止
-		zcgoLogger.Panicf(msgPrefix+msg, params...)
-	}
-	if msgLogLevel == LOG_LEVEL_FATAL {
-		msgPrefix := fmt.Sprintf("%s 代码:%s %d 函数:%s ", LogLevels[msgLogLevel], file, line, myFunc)
-		// 输出fatal日志并终止程序
-		zcgoLogger.Fatalf(msgPrefix+msg, params...)
-	}
-	// 获取函数对应的日志级别
-	myLevel := logLevelCtl[myFunc]
-	if myLevel == 0 {
-		// 没有特别指定调用方函数的日志级别时，使用全局日志级别
-		myLevel = Level
+	// Panic与Fatal无视日志级别，在之前推送的日志全部输出之后输出，然后抛出panic或终止程序
+	if msgLogLevel == LOG_LEVEL_PANIC || msgLogLevel == LOG_LEVEL_FATAL {
+		pushMsg := l.newLogMsg(msgLogLevel, file, line, runtime.FuncForPC(pc).Name(), msg, ctx, formatted, params, fields)
+		l.panicOrExit(pushMsg)
+		return
 	}
 	// 判断该日志是否需要输出
-	if myLevel > msgLogLevel {
+	if !l.levelEnabled(pc, msgLogLevel) {
 		return
 	}
+	// 调用处函数包路径，确定需要输出后再获取
+	myFunc := runtime.FuncForPC(pc).Name()
+	pushMsg := l.newLogMsg(msgLogLevel, file, line, myFunc, msg, ctx, formatted, params, fields)
+	l.dispatchLogMsg(pushMsg)
+}
+
+// GetLevel 获取Logger的全局日志级别
+func (l *Logger) GetLevel() int {
+	return int(l.level.Load())
+}
+
+// SetLevel 修改Logger的全局日志级别
+//
+//	可以在日志输出的同时并发调用；指定了日志级别的函数不受全局日志级别影响。
+func (l *Logger) SetLevel(level int) {
+	l.level.Store(int64(level))
+	if l.isDefault {
+		syncDeprecatedLevel(level)
+	}
+}
+
+// 判断调用处的日志是否需要输出
+//
+//	pc 调用处的程序计数器，用于匹配指定函数、类型或包的日志级别
+func (l *Logger) levelEnabled(pc uintptr, msgLogLevel int) bool {
+	// 获取调用处函数对应的日志级别
+	myLevel := getCallerLogLevel(pc)
+	if myLevel == 0 {
+		// 没有特别指定调用方函数的日志级别时，使用全局日志级别
+		myLevel = l.GetLevel()
+	}
+	return myLevel <= msgLogLevel
+}
+
+// 输出日志消息
+func (l *Logger) dispatchLogMsg(pushMsg logMsg) {
 	// 根据日志模式判断同步还是异步输出
-	switch zcgologConfig.LogMod {
+	switch l.config.LogMod {
 	case LOG_MODE_SERVER:
-		pushMsg := logMsg{
-			pushTime:  time.Now(),
-			logLevel:  msgLogLevel,
-			callFile:  file,
-			callLine:  line,
-			callFunc:  myFunc,
-			logMsg:    msg,
-			logParams: params,
-		}
-		if msgReaderRunning {
+		if l.msgReaderRunning.Load() {
 			// 将日志消息推送到日志缓冲通道
-			pushMsgToLogMsgChn(pushMsg)
+			l.pushMsgToLogMsgChn(pushMsg)
 		} else {
 			// 服务器模式下日志缓冲通道监听服务已停止时，直接输出日志
-			msgPrefix := fmt.Sprintf("%s 代码:%s %d 函数:%s ", LogLevels[msgLogLevel], file, line, myFunc)
-			zcgoLogger.Printf(msgPrefix+msg, params...)
+			l.outputMsg(&pushMsg)
 		}
 	case LOG_MODE_LOCAL:
 		// 本地日志模式下，直接输出日志
-		msgPrefix := fmt.Sprintf("%s 代码:%s %d 函数:%s ", LogLevels[msgLogLevel], file, line, myFunc)
-		zcgoLogger.Printf(msgPrefix+msg, params...)
+		l.outputMsg(&pushMsg)
 	default:
 		panic("unhandled default case")
 	}
 }
+
+// 生成日志消息
+func (l *Logger) newLogMsg(msgLogLevel int, file string, line int, myFunc string, msg string, ctx context.Context, formatted bool, params []interface{}, fields []Field) logMsg {
+	// 上下文字段在日志确定需要输出后再提取，放在本次日志的字段之前
+	if ctx != nil {
+		if ctxFields := contextFields(ctx); len(ctxFields) > 0 {
+			fields = append(ctxFields, fields...)
+		}
+	}
+	// 合并绑定字段与本次日志的字段，通过限制容量确保append时复制而不会修改绑定字段
+	if len(l.fields) > 0 {
+		fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	}
+	pushMsg := logMsg{
+		pushTime:  time.Now(),
+		logLevel:  msgLogLevel,
+		callFile:  file,
+		callLine:  line,
+		callFunc:  myFunc,
+		logMsg:    msg,
+		formatted: formatted,
+		logParams: params,
+		fields:    fields,
+	}
+	// goroutine ID只能在调用方所在的goroutine中获取
+	if l.needGoid {
+		pushMsg.goid = getGoroutineID()
+	}
+	return pushMsg
+}
+
+// 输出日志消息，配置了日志输出目标时输出到各个目标，否则由stdLogger输出；同时输出到错误日志文件
+func (l *Logger) outputMsg(msg *logMsg) {
+	if !l.writeSinks(msg) {
+		l.stdLogger.Print(l.formatLogLine(msg))
+	}
+	l.writeErrorFile(msg)
+}
+
+// 按当前日志编码器渲染日志消息
+func (l *Logger) formatLogLine(msg *logMsg) string {
+	return string(l.encoder.encode(nil, msg))
+}
+
+// 获取stdLogger的日志前缀格式
+//
+//	JSON编码时每行必须是完整的JSON对象，因此不输出写入时间前缀
+func (l *Logger) loggerFlags() int {
+	if l.config.LogEncoder == LOG_ENCODER_JSON {
+		return 0
+	}
+	return log.Ldate | log.Ltime
+}
@@ -0,0 +1,163 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_compress.go 滚动后的日志文件压缩
+*/
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// 内置的日志文件压缩方式
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_COMPRESSION_GZIP gzip压缩，压缩后的文件扩展名为.gz
+	LOG_COMPRESSION_GZIP = "gzip"
+)
+
+// Compressor 日志文件压缩器
+//
+//	可以通过 RegisterCompressor 注册自定义的压缩方式(如zstd)，并在配置LogFileCompression中指定其名称。
+type Compressor interface {
+	// Ext 压缩后文件的扩展名，会追加在日志文件名之后，如 ".gz"
+	Ext() string
+	// Compress 将src的内容压缩后写入dst
+	Compress(dst io.Writer, src io.Reader) error
+}
+
+// gzip压缩器
+type gzipCompressor struct{}
+
+func (gzipCompressor) Ext() string {
+	return ".gz"
+}
+
+func (gzipCompressor) Compress(dst io.Writer, src io.Reader) error {
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		_ = gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// 已注册的压缩器，key为压缩方式名称
+var compressors = map[string]Compressor{
+	LOG_COMPRESSION_GZIP: gzipCompressor{},
+}
+
+// 注册与读取压缩器时使用的锁
+var compressorsLock sync.RWMutex
+
+// RegisterCompressor 注册日志文件压缩器
+//
+//	name为压缩方式名称，供配置LogFileCompression使用；同名压缩器会被覆盖。
+//	压缩器需要在初始化使用该压缩方式的Logger之前注册。
+func RegisterCompressor(name string, compressor Compressor) {
+	if name == "" || compressor == nil {
+		panic("zclog: 注册日志文件压缩器时name与compressor不能为空")
+	}
+	compressorsLock.Lock()
+	defer compressorsLock.Unlock()
+	compressors[name] = compressor
+}
+
+// 根据压缩方式名称获取压缩器
+func getCompressor(name string) (Compressor, bool) {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+	compressor, ok := compressors[name]
+	return compressor, ok
+}
+
+// 获取所有已注册压缩器的扩展名，用于识别压缩后的日志文件
+func compressedExts() []string {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+	var exts []string
+	for _, compressor := range compressors {
+		exts = append(exts, compressor.Ext())
+	}
+	return exts
+}
+
+// 压缩日志文件，压缩成功后删除原文件
+//
+//	先写入临时文件再重命名，避免出现不完整的压缩文件；压缩文件采用配置的日志文件权限与属组。
+func compressLogFile(logConfig *Config, compressor Compressor, logFilePath string) error {
+	src, err := os.Open(logFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	targetPath := logFilePath + compressor.Ext()
+	tmpPath := targetPath + ".tmp"
+	dst, err := createLogFile(logConfig, tmpPath, os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	err = compressor.Compress(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	err = errors.Join(err, dst.Close())
+	if err == nil {
+		err = os.Rename(tmpPath, targetPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(logFilePath)
+}
+
+// 将滚动后的日志文件加入待压缩列表，由历史日志文件清理任务在清理前压缩
+//
+//	需要在持有lock时调用。
+func (w *logFileWriter) queueLogFileCompression(logFilePath string) {
+	if w.config.LogFileCompression == "" {
+		return
+	}
+	w.compressLock.Lock()
+	defer w.compressLock.Unlock()
+	w.compressQueue = append(w.compressQueue, logFilePath)
+}
+
+// 压缩待压缩列表中的日志文件，由历史日志文件清理任务调用
+func (w *logFileWriter) compressQueuedLogFiles(logConfig *Config) {
+	w.compressLock.Lock()
+	queue := w.compressQueue
+	w.compressQueue = nil
+	w.compressLock.Unlock()
+	if len(queue) == 0 {
+		return
+	}
+	compressor, ok := getCompressor(logConfig.LogFileCompression)
+	if !ok {
+		return
+	}
+	for _, logFilePath := range queue {
+		if err := compressLogFile(logConfig, compressor, logFilePath); err != nil {
+			fmt.Printf("zclog 压缩日志文件 %s 发生错误: %s\n", logFilePath, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressRotatedLogFiles(t *testing.T) {
+	fmt.Println("----- TestCompressRotatedLogFiles -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:    true,
+		LogFileDir:         logDir,
+		LogMod:             LOG_MODE_SERVER,
+		LogChnOverPolicy:   LOG_CHN_OVER_POLICY_BLOCK,
+		LogFileMaxSizeM:    1,
+		LogFileCompression: LOG_COMPRESSION_GZIP,
+		LogLineFormat:      "%level %msg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("测试日志", 64)
+	for i := 0; i < 3000; i++ {
+		logger.Info(msg)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	gzFiles, _ := filepath.Glob(path.Join(logDir, "zcgolog_*_*.log.gz"))
+	logFiles, _ := filepath.Glob(path.Join(logDir, "zcgolog_*_*.log"))
+	if len(gzFiles) < 2 || len(logFiles) != 1 {
+		t.Fatalf("滚动后的日志文件应该被压缩, 只保留当前日志文件: gz: %v, log: %v", gzFiles, logFiles)
+	}
+	// 压缩后的日志文件序列号应该早于当前日志文件
+	for _, gzFile := range gzFiles {
+		if strings.TrimSuffix(gzFile, ".gz") >= logFiles[0] {
+			t.Fatalf("压缩后的日志文件序列号不符合预期: %s, 当前日志文件: %s", gzFile, logFiles[0])
+		}
+	}
+	f, err := os.Open(gzFiles[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) < 1024*1024 || !strings.Contains(string(content), "INFO] "+msg) {
+		t.Fatal("压缩后的日志文件内容不符合预期")
+	}
+	tmpFiles, _ := filepath.Glob(path.Join(logDir, "*.tmp"))
+	if len(tmpFiles) > 0 {
+		t.Fatalf("压缩完成后不应该残留临时文件: %v", tmpFiles)
+	}
+}
+
+func TestCompressWithRetention(t *testing.T) {
+	fmt.Println("----- TestCompressWithRetention -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:    true,
+		LogFileDir:         logDir,
+		LogMod:             LOG_MODE_SERVER,
+		LogChnOverPolicy:   LOG_CHN_OVER_POLICY_BLOCK,
+		LogFileMaxSizeM:    1,
+		LogFileCompression: LOG_COMPRESSION_GZIP,
+		LogFileMaxCount:    2,
+		LogLineFormat:      "%level %msg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("测试日志", 64)
+	for i := 0; i < 6000; i++ {
+		logger.Info(msg)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 压缩完成后再执行保留策略，最终只保留当前日志文件与最近一个压缩后的日志文件
+	gzFiles, _ := filepath.Glob(path.Join(logDir, "zcgolog_*_*.log.gz"))
+	logFiles, _ := filepath.Glob(path.Join(logDir, "zcgolog_*_*.log"))
+	tmpFiles, _ := filepath.Glob(path.Join(logDir, "*.tmp"))
+	if len(gzFiles) != 1 || len(logFiles) != 1 || len(tmpFiles) > 0 {
+		t.Fatalf("压缩与保留策略的执行结果不符合预期: gz: %v, log: %v, tmp: %v", gzFiles, logFiles, tmpFiles)
+	}
+	if strings.TrimSuffix(gzFiles[0], ".gz") >= logFiles[0] {
+		t.Fatalf("保留的压缩后日志文件应该是最近滚动的日志文件: %s, 当前日志文件: %s", gzFiles[0], logFiles[0])
+	}
+}
+
+func TestNextNumberWithCompressedLogFile(t *testing.T) {
+	fmt.Println("----- TestNextNumberWithCompressedLogFile -----")
+	logDir := t.TempDir()
+	config := newDefaultConfig()
+	config.LogFileDir = logDir
+	period := getRotatePeriod(config, time.Now())
+	for _, name := range []string{"zcgolog_" + period + "_00001.log.gz", "zcgolog_" + period + "_00002.log.gz"} {
+		if err := os.WriteFile(path.Join(logDir, name), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logFilePath, _, err := GetLogFilePathAndYMDToday(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(logFilePath) != "zcgolog_"+period+"_00003.log" {
+		t.Fatalf("最新日志文件已压缩时应该创建新的日志文件: %s", logFilePath)
+	}
+	// 同一序列号同时存在未压缩与压缩后的文件时，说明正在压缩，不能继续写入
+	if err := os.WriteFile(path.Join(logDir, "zcgolog_"+period+"_00003.log.gz"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	logFilePath, _, err = GetLogFilePathAndYMDToday(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(logFilePath) != "zcgolog_"+period+"_00004.log" {
+		t.Fatalf("正在压缩的日志文件不能继续写入: %s", logFilePath)
+	}
+}
+
+// 测试用的自定义压缩器，不做压缩直接复制
+type copyCompressor struct{}
+
+func (copyCompressor) Ext() string {
+	return ".copy"
+}
+
+func (copyCompressor) Compress(dst io.Writer, src io.Reader) error {
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+func TestRegisterCompressor(t *testing.T) {
+	fmt.Println("----- TestRegisterCompressor -----")
+	copyConfig := newDefaultConfig()
+	copyConfig.LogFileCompression = "copy"
+	if _, err := CheckConfig(copyConfig); err == nil || !strings.Contains(err.Error(), "压缩方式") {
+		t.Fatal("未注册的压缩方式应该校验失败")
+	}
+	RegisterCompressor("copy", copyCompressor{})
+	defer func() {
+		compressorsLock.Lock()
+		delete(compressors, "copy")
+		compressorsLock.Unlock()
+	}()
+	logDir := t.TempDir()
+	logFilePath := path.Join(logDir, "zcgolog_20220507_00001.log")
+	if err := os.WriteFile(logFilePath, []byte("测试日志\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	compressor, _ := getCompressor("copy")
+	if err := compressLogFile(newDefaultConfig(), compressor, logFilePath); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(logFilePath + ".copy")
+	if err != nil || string(content) != "测试日志\n" {
+		t.Fatalf("自定义压缩器压缩结果不符合预期: %s, %v", content, err)
+	}
+	if _, err := os.Stat(logFilePath); !os.IsNotExist(err) {
+		t.Fatal("压缩完成后原日志文件应该被删除")
+	}
+	config := newDefaultConfig()
+	config.LogFileDir = logDir
+	namer, err := parseLogFileNamePattern(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !namer.regexp("").MatchString("zcgolog_20220507_00001.log.copy") {
+		t.Fatal("保留策略应该识别自定义压缩器压缩后的日志文件")
+	}
+}
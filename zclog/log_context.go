@@ -0,0 +1,134 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_context.go context.Context相关处理，包括上下文字段提取器注册，以及在context中存取Logger与字段
+*/
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ContextExtractor 上下文字段提取器，从context中提取需要附加到日志中的字段，如trace_id、span_id、tenant等
+type ContextExtractor func(ctx context.Context) []Field
+
+// 已注册的上下文字段提取器
+type namedContextExtractor struct {
+	name      string
+	extractor ContextExtractor
+}
+
+// 上下文字段提取器列表，写时复制，日志输出时无锁读取
+var contextExtractors atomic.Pointer[[]namedContextExtractor]
+
+// 注册与注销上下文字段提取器时使用的锁
+var contextExtractorsLock sync.Mutex
+
+// RegisterContextExtractor 注册上下文字段提取器
+//
+//	name 提取器名称，重复注册同名提取器时替换原有的提取器；
+//	提取器按注册顺序执行，只在日志确定需要输出时执行。
+func RegisterContextExtractor(name string, extractor ContextExtractor) {
+	contextExtractorsLock.Lock()
+	defer contextExtractorsLock.Unlock()
+	var extractors []namedContextExtractor
+	if current := contextExtractors.Load(); current != nil {
+		extractors = make([]namedContextExtractor, 0, len(*current)+1)
+		for _, e := range *current {
+			if e.name != name {
+				extractors = append(extractors, e)
+			}
+		}
+	}
+	extractors = append(extractors, namedContextExtractor{name: name, extractor: extractor})
+	contextExtractors.Store(&extractors)
+}
+
+// UnregisterContextExtractor 注销上下文字段提取器
+func UnregisterContextExtractor(name string) {
+	contextExtractorsLock.Lock()
+	defer contextExtractorsLock.Unlock()
+	current := contextExtractors.Load()
+	if current == nil {
+		return
+	}
+	extractors := make([]namedContextExtractor, 0, len(*current))
+	for _, e := range *current {
+		if e.name != name {
+			extractors = append(extractors, e)
+		}
+	}
+	contextExtractors.Store(&extractors)
+}
+
+// context中存放Logger与字段使用的key
+type loggerContextKey struct{}
+type fieldsContextKey struct{}
+
+// NewContext 将Logger存入context
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext 从context中获取Logger，context中没有Logger时返回默认Logger
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerContextKey{}).(*Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return defaultLogger
+}
+
+// ContextWithFields 将字段存入context，context中已有的字段会保留，新字段追加在其后
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func ContextWithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	fields := buildFields(keysAndValues)
+	if len(fields) == 0 {
+		return ctx
+	}
+	current := FieldsFromContext(ctx)
+	merged := make([]Field, 0, len(current)+len(fields))
+	merged = append(merged, current...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsContextKey{}, merged)
+}
+
+// FieldsFromContext 获取通过ContextWithFields存入context的字段
+//
+//	返回的切片不可修改
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsContextKey{}).([]Field)
+	return fields
+}
+
+// 获取需要附加到日志中的上下文字段，包括存入context的字段与各提取器提取的字段
+//
+//	返回的切片总是新分配的，调用方可以直接在其后追加
+func contextFields(ctx context.Context) []Field {
+	var fields []Field
+	if stored := FieldsFromContext(ctx); len(stored) > 0 {
+		fields = append(fields, stored...)
+	}
+	if extractors := contextExtractors.Load(); extractors != nil {
+		for _, e := range *extractors {
+			fields = append(fields, e.extractor(ctx)...)
+		}
+	}
+	return fields
+}
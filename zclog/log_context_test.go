@@ -0,0 +1,95 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type traceIDKey struct{}
+
+func TestContextLog(t *testing.T) {
+	fmt.Println("----- TestContextLog -----")
+	extractCount := 0
+	RegisterContextExtractor("trace", func(ctx context.Context) []Field {
+		extractCount++
+		if traceID, ok := ctx.Value(traceIDKey{}).(string); ok {
+			return []Field{String("trace_id", traceID)}
+		}
+		return nil
+	})
+	defer UnregisterContextExtractor("trace")
+
+	logger, err := NewLogger(&Config{LogLevelGlobal: LOG_LEVEL_INFO, LogLineFormat: "%level %callFunc %msg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger.stdLogger.SetOutput(&buf)
+	logger.stdLogger.SetFlags(0)
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace-1")
+	ctx = ContextWithFields(ctx, "tenant", "t1")
+	ctx = NewContext(ctx, logger.With("component", "api"))
+	if FromContext(context.Background()) != defaultLogger {
+		t.Fatal("context中没有Logger时应该返回默认Logger")
+	}
+
+	DebugCtx(ctx, "DEBUG日志不输出")
+	if extractCount != 0 {
+		t.Fatal("日志不需要输出时不应该执行上下文字段提取器")
+	}
+	InfoCtx(ctx, "包级别函数使用context中的Logger")
+	WarnfCtx(ctx, "格式化: %d", 1)
+	logger.ErrorCtx(context.Background(), "没有上下文字段")
+	logger.ErrorfCtx(ContextWithFields(ctx, "user", 1001), "追加字段: %s", "ok")
+
+	fmt.Print(buf.String())
+	caller := "gitee.com/zhaochuninhefei/zcgolog/zclog.TestContextLog"
+	expected := []string{
+		"[ INFO] " + caller + " 包级别函数使用context中的Logger component=api tenant=t1 trace_id=trace-1",
+		"[ WARN] " + caller + " 格式化: 1 component=api tenant=t1 trace_id=trace-1",
+		"[ERROR] " + caller + " 没有上下文字段",
+		"[ERROR] " + caller + " 追加字段: ok tenant=t1 user=1001 trace_id=trace-1",
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("日志行数不符合预期: %d", len(lines))
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("第%d行日志不符合预期: %s", i+1, lines[i])
+		}
+	}
+	if len(FieldsFromContext(ctx)) != 1 {
+		t.Fatal("ContextWithFields不应修改原context中的字段")
+	}
+}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	fmt.Println("----- TestRegisterContextExtractor -----")
+	RegisterContextExtractor("a", func(ctx context.Context) []Field { return []Field{Int("a", 1)} })
+	RegisterContextExtractor("b", func(ctx context.Context) []Field { return []Field{Int("b", 1)} })
+	RegisterContextExtractor("a", func(ctx context.Context) []Field { return []Field{Int("a", 2)} })
+	fields := contextFields(context.Background())
+	if len(fields) != 2 || fields[0] != Int("b", 1) || fields[1] != Int("a", 2) {
+		t.Fatalf("重复注册同名提取器时应该替换原有提取器: %v", fields)
+	}
+	UnregisterContextExtractor("a")
+	UnregisterContextExtractor("b")
+	if fields := contextFields(context.Background()); len(fields) != 0 {
+		t.Fatalf("注销后不应再执行提取器: %v", fields)
+	}
+}
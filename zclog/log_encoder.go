@@ -0,0 +1,113 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_encoder.go 日志输出编码，支持文本格式与JSON格式
+*/
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// 日志输出编码定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_ENCODER_TEXT 文本编码，按照LogLineFormat渲染日志行
+	LOG_ENCODER_TEXT = iota + 1
+	// LOG_ENCODER_JSON JSON编码，每条日志输出为一行JSON对象
+	LOG_ENCODER_JSON
+	// log_encoder_max 日志输出编码定义值上限
+	log_encoder_max
+)
+
+// 日志编码器，将日志消息编码后追加到buf并返回
+type logEncoder interface {
+	encode(buf []byte, msg *logMsg) []byte
+}
+
+// 文本编码器即日志行格式模板
+func (f *lineFormatter) encode(buf []byte, msg *logMsg) []byte {
+	return f.format(buf, msg)
+}
+
+// JSON编码器
+//
+//	输出字段: level, time(RFC3339Nano), file, line, func, msg，以及结构化日志字段
+type jsonEncoder struct{}
+
+func (jsonEncoder) encode(buf []byte, msg *logMsg) []byte {
+	buf = append(buf, `{"level":`...)
+	buf = appendJSONString(buf, GetLogLevelStrByInt(msg.logLevel))
+	buf = append(buf, `,"time":"`...)
+	buf = msg.pushTime.AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, `","file":`...)
+	buf = appendJSONString(buf, msg.callFile)
+	buf = append(buf, `,"line":`...)
+	buf = strconv.AppendInt(buf, int64(msg.callLine), 10)
+	buf = append(buf, `,"func":`...)
+	buf = appendJSONString(buf, msg.callFunc)
+	buf = append(buf, `,"msg":`...)
+	buf = appendJSONString(buf, string(msg.appendMessage(nil)))
+	buf = appendJSONFields(buf, msg.fields)
+	buf = append(buf, '}')
+	return buf
+}
+
+const hexDigits = "0123456789abcdef"
+
+// 将字符串编码为JSON字符串追加到buf
+//
+//	对引号、反斜杠与控制字符做转义，非法的UTF-8字节替换为�
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `�`...)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	buf = append(buf, '"')
+	return buf
+}
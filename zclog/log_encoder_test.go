@@ -0,0 +1,94 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAppendJSONString(t *testing.T) {
+	fmt.Println("----- TestAppendJSONString -----")
+	inputs := map[string]string{
+		"":            "",
+		"普通文本":        "普通文本",
+		`带"引号"与\\反斜杠`: `带"引号"与\\反斜杠`,
+		"换行\n回车\r制表\t控制\x01字符": "换行\n回车\r制表\t控制\x01字符",
+		"非法UTF-8:\xff\xfe":     "非法UTF-8:��",
+	}
+	for input, expected := range inputs {
+		encoded := appendJSONString(nil, input)
+		fmt.Println(string(encoded))
+		var decoded string
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("%q 编码结果不是合法的JSON字符串: %s", input, err)
+		}
+		if expected != decoded {
+			t.Fatalf("%q 编码后解码结果不一致: %q", input, decoded)
+		}
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	fmt.Println("----- TestJSONEncoder -----")
+	pushTime := time.Date(2022, 5, 7, 16, 56, 39, 123456789, time.UTC)
+	msg := &logMsg{
+		pushTime:  pushTime,
+		logLevel:  LOG_LEVEL_ERROR,
+		callFile:  "/home/zhaochun/zcgolog/zclog/log_test.go",
+		callLine:  56,
+		callFunc:  "gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog",
+		logMsg:    "测试\"日志\"\n第%d行",
+		formatted: true,
+		logParams: []interface{}{2},
+	}
+	line := jsonEncoder{}.encode(nil, msg)
+	fmt.Println(string(line))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"level": "error",
+		"time":  "2022-05-07T16:56:39.123456789Z",
+		"file":  "/home/zhaochun/zcgolog/zclog/log_test.go",
+		"line":  float64(56),
+		"func":  "gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog",
+		"msg":   "测试\"日志\"\n第2行",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Fatalf("JSON字段 %s 不符合预期: %v", key, entry[key])
+		}
+	}
+}
+
+func TestLocalLogJSON(t *testing.T) {
+	fmt.Println("----- TestLocalLogJSON -----")
+	InitLogger(&Config{LogEncoder: LOG_ENCODER_JSON})
+	defer InitLogger(&Config{LogEncoder: LOG_ENCODER_TEXT})
+	var buf bytes.Buffer
+	originalOutput := defaultLogger.stdLogger.Writer()
+	defaultLogger.stdLogger.SetOutput(&buf)
+	Warnf("测试JSON日志: %d", 1)
+	defaultLogger.stdLogger.SetOutput(originalOutput)
+	fmt.Print(buf.String())
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("本地模式JSON日志不是合法的JSON: %s", err)
+	}
+	if entry["level"] != "warning" || entry["msg"] != "测试JSON日志: 1" || entry["func"] != "gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogJSON" {
+		t.Fatalf("本地模式JSON日志内容不符合预期: %s", buf.String())
+	}
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_error_file.go 错误日志文件，将不低于指定级别的日志额外输出到单独的日志文件，便于排查问题时快速定位
+*/
+
+import (
+	"fmt"
+)
+
+// LOG_ERROR_FILE_NAME_SUFFIX 错误日志文件名前缀的后缀，错误日志文件名前缀为 [LogFileNamePrefix]_error
+//
+//goland:noinspection GoSnakeCaseUsage
+const LOG_ERROR_FILE_NAME_SUFFIX = "_error"
+
+// 获取错误日志文件名前缀
+func errorFileNamePrefix(logConfig *Config) string {
+	return logConfig.LogFileNamePrefix + LOG_ERROR_FILE_NAME_SUFFIX
+}
+
+// 检查错误日志文件配置
+func checkErrorFileConfig(logConfig *Config) error {
+	if logConfig.LogErrorFileLevel != 0 && (logConfig.LogErrorFileLevel < LOG_LEVEL_DEBUG || logConfig.LogErrorFileLevel >= log_level_max) {
+		return fmt.Errorf("错误日志文件的日志级别不能超出有效范围")
+	}
+	return nil
+}
+
+// 根据配置打开错误日志文件，已打开的错误日志文件会先关闭
+//
+//	LogErrorFileLevel为0或LogFileDir为空时不打开；错误日志文件沿用Logger的日志文件配置、编码与日志行格式，
+//	因此与主日志文件采用相同的滚动、压缩与保留策略。
+func (l *Logger) openErrorFile() error {
+	if err := l.closeErrorFile(); err != nil {
+		return err
+	}
+	if l.config.LogErrorFileLevel == 0 || l.config.LogFileDir == "" {
+		return nil
+	}
+	sink, err := l.newFileSink(errorFileNamePrefix(l.config), l.config.LogEncoder, l.config.LogLineFormat)
+	if err != nil {
+		return fmt.Errorf("打开错误日志文件发生错误: %w", err)
+	}
+	l.errorFile.Store(sink)
+	return nil
+}
+
+// 关闭错误日志文件
+func (l *Logger) closeErrorFile() error {
+	if sink := l.errorFile.Swap(nil); sink != nil {
+		return sink.Close()
+	}
+	return nil
+}
+
+// 将不低于LogErrorFileLevel的日志消息输出到错误日志文件
+func (l *Logger) writeErrorFile(msg *logMsg) {
+	sink := l.errorFile.Load()
+	if sink == nil || msg.logLevel < l.config.LogErrorFileLevel {
+		return
+	}
+	if err := sink.Write(newEntry(msg)); err != nil {
+		fmt.Printf("zclog 日志输出到错误日志文件发生错误: %s\n", err)
+	}
+}
+
+// 将错误日志文件写入缓冲区中的日志批量写入
+func (l *Logger) flushErrorFile() error {
+	if sink := l.errorFile.Load(); sink != nil {
+		return sink.Flush()
+	}
+	return nil
+}
+
+// 将错误日志文件落盘
+func (l *Logger) syncErrorFile() error {
+	if sink := l.errorFile.Load(); sink != nil {
+		return sink.Sync()
+	}
+	return nil
+}
+
+// 重新打开错误日志文件
+func (l *Logger) reopenErrorFile() error {
+	if sink := l.errorFile.Load(); sink != nil {
+		return sink.writer.reopen()
+	}
+	return nil
+}
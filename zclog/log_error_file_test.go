@@ -0,0 +1,83 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestErrorFile(t *testing.T) {
+	fmt.Println("----- TestErrorFile -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:   true,
+		LogFileDir:        logDir,
+		LogMod:            LOG_MODE_SERVER,
+		LogErrorFileLevel: LOG_LEVEL_WARNING,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("普通日志")
+	logger.Warn("警告日志")
+	logger.Errorf("错误日志: %d", 500)
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	readLogFiles := func(config *Config) string {
+		logFiles, err := listLogFiles(config)
+		if err != nil || len(logFiles) != 1 {
+			t.Fatalf("日志文件数量不符合预期: %v, %v", logFiles, err)
+		}
+		content, err := os.ReadFile(path.Join(logDir, logFiles[0].name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	// 主日志文件包含所有日志，且保留策略不会识别错误日志文件
+	mainContent := readLogFiles(logger.config)
+	if !strings.Contains(mainContent, "普通日志") || !strings.Contains(mainContent, "警告日志") || !strings.Contains(mainContent, "错误日志: 500") {
+		t.Fatalf("主日志文件内容不符合预期: %s", mainContent)
+	}
+	// 错误日志文件只包含不低于LogErrorFileLevel的日志
+	errorConfig := *logger.config
+	errorConfig.LogFileNamePrefix = errorFileNamePrefix(logger.config)
+	errorContent := readLogFiles(&errorConfig)
+	if strings.Contains(errorContent, "普通日志") || !strings.Contains(errorContent, "警告日志") || !strings.Contains(errorContent, "错误日志: 500") {
+		t.Fatalf("错误日志文件内容不符合预期: %s", errorContent)
+	}
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logger.errorFile.Load() != nil {
+		t.Fatal("Shutdown之后应该关闭错误日志文件")
+	}
+
+	// 错误日志文件配置校验
+	config := newDefaultConfig()
+	config.LogErrorFileLevel = log_level_max
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("错误日志文件的日志级别超出有效范围时应该校验失败")
+	}
+	config = newDefaultConfig()
+	config.LogFileDir = logDir
+	config.LogErrorFileLevel = LOG_LEVEL_ERROR
+	config.LogSinks = []SinkConfig{{Type: LOG_SINK_FILE, FileNamePrefix: "zcgolog_error"}}
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("日志文件输出目标与错误日志文件使用相同的日志文件名前缀时应该校验失败")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_exit.go Panic与Fatal日志的输出，以及Fatal终止程序前执行的退出钩子
+*/
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// 终止程序的函数，测试时替换以避免进程退出
+var osExit = os.Exit
+
+// 退出钩子列表
+var exitHooks []func()
+
+// 注册与执行退出钩子时使用的锁
+var exitHooksLock sync.Mutex
+
+// 是否已经开始执行退出钩子并终止程序，退出钩子中再次输出Fatal日志时直接终止程序，避免递归执行退出钩子
+var exiting atomic.Bool
+
+// RegisterExitHook 注册退出钩子
+//
+//	Fatal日志输出并落盘之后、终止程序之前，按注册顺序执行退出钩子，用于关闭数据库连接等资源；
+//	钩子中发生的panic会被捕获并输出到控制台，不影响后续钩子的执行；
+//	钩子中输出的日志会在终止程序之前落盘；
+//	钩子中输出Fatal日志时，该日志落盘后直接终止程序，不再执行其余的退出钩子。
+func RegisterExitHook(hook func()) {
+	exitHooksLock.Lock()
+	defer exitHooksLock.Unlock()
+	exitHooks = append(exitHooks, hook)
+}
+
+// 按注册顺序执行退出钩子
+func runExitHooks() {
+	exitHooksLock.Lock()
+	hooks := make([]func(), len(exitHooks))
+	copy(hooks, exitHooks)
+	exitHooksLock.Unlock()
+	for _, hook := range hooks {
+		runExitHook(hook)
+	}
+}
+
+func runExitHook(hook func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "zclog退出钩子发生panic: %v\n", r)
+		}
+	}()
+	hook()
+}
+
+// 输出Panic或Fatal日志，然后抛出panic或终止程序
+//
+//	服务器模式下，该日志同样推送到日志缓冲通道，保证在之前推送的日志之后输出，并等待其写入日志文件并落盘；
+//	推送不受LogChnOverPolicy影响，日志缓冲通道已满时会等待而不会被丢弃。
+//	Panic日志输出后抛出panic，panic的值为日志行内容；
+//	Fatal日志输出后执行退出钩子，再次落盘后以状态码1终止程序；
+//	已经在执行退出钩子时(如钩子中输出Fatal日志)，不再执行退出钩子，直接终止程序。
+func (l *Logger) panicOrExit(pushMsg logMsg) {
+	if !l.pushMsgAndSync(pushMsg) {
+		l.outputMsg(&pushMsg)
+		_ = l.syncCurrentLogFile()
+	}
+	if pushMsg.logLevel == LOG_LEVEL_PANIC {
+		panic(l.formatLogLine(&pushMsg))
+	}
+	if exiting.Swap(true) {
+		osExit(1)
+		return
+	}
+	runExitHooks()
+	_ = l.Sync()
+	osExit(1)
+}
+
+// 服务器模式下将日志消息推送到日志缓冲通道并等待其落盘，日志缓冲通道监听未运行时返回false
+func (l *Logger) pushMsgAndSync(pushMsg logMsg) bool {
+	if l.config.LogMod != LOG_MODE_SERVER || !l.msgReaderRunning.Load() {
+		return false
+	}
+	select {
+	case l.logMsgChn <- pushMsg:
+	case <-l.msgReaderStopped:
+		// 监听已停止，停止前已输出剩余日志
+		return false
+	}
+	_ = l.Sync()
+	return true
+}
@@ -0,0 +1,139 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPanicAndFatalFlush(t *testing.T) {
+	fmt.Println("----- TestPanicAndFatalFlush -----")
+	exitCode := 0
+	origExit := osExit
+	osExit = func(code int) {
+		exitCode = code
+	}
+	defer func() {
+		osExit = origExit
+		exiting.Store(false)
+		exitHooksLock.Lock()
+		exitHooks = nil
+		exitHooksLock.Unlock()
+	}()
+	var hookCalls []string
+	RegisterExitHook(func() {
+		hookCalls = append(hookCalls, "hook1")
+	})
+	RegisterExitHook(func() {
+		panic("退出钩子发生panic")
+	})
+	RegisterExitHook(func() {
+		hookCalls = append(hookCalls, "hook3")
+	})
+
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      logDir,
+		LogMod:          LOG_MODE_SERVER,
+		LogLineFormat:   "%level %msg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = logger.QuitMsgReader(3000)
+	}()
+	for i := 0; i < 1000; i++ {
+		logger.Infof("Panic之前的日志: %d", i+1)
+	}
+	panicValue := func() (r interface{}) {
+		defer func() {
+			r = recover()
+		}()
+		logger.Panicf("Panic日志: %d", 1)
+		return nil
+	}()
+	if panicValue == nil || !strings.HasSuffix(fmt.Sprint(panicValue), "[PANIC] Panic日志: 1") {
+		t.Fatalf("Panic应该抛出以日志行为值的panic: %v", panicValue)
+	}
+	// Panic返回时，之前推送的日志与Panic日志应该已经按顺序写入日志文件
+	lines := strings.Split(strings.TrimSpace(readSingleLogFile(t, logDir, "zcgolog_")), "\n")
+	if len(lines) != 1002 || !strings.HasSuffix(lines[1000], "Panic之前的日志: 1000") || !strings.HasSuffix(lines[1001], "[PANIC] Panic日志: 1") {
+		t.Fatalf("Panic日志应该在之前推送的日志之后输出, 日志行数: %d", len(lines))
+	}
+
+	for i := 0; i < 1000; i++ {
+		logger.Infof("Fatal之前的日志: %d", i+1)
+	}
+	logger.Fatal("Fatal日志")
+	lines = strings.Split(strings.TrimSpace(readSingleLogFile(t, logDir, "zcgolog_")), "\n")
+	if len(lines) != 2003 || !strings.HasSuffix(lines[2001], "Fatal之前的日志: 1000") || !strings.HasSuffix(lines[2002], "[FATAL] Fatal日志") {
+		t.Fatalf("Fatal日志应该在之前推送的日志之后输出并落盘, 日志行数: %d", len(lines))
+	}
+	if exitCode != 1 {
+		t.Fatalf("Fatal应该以状态码1终止程序: %d", exitCode)
+	}
+	if strings.Join(hookCalls, ",") != "hook1,hook3" {
+		t.Fatalf("退出钩子应该按注册顺序执行，且不受其他钩子panic影响: %v", hookCalls)
+	}
+}
+
+func TestFatalInExitHook(t *testing.T) {
+	fmt.Println("----- TestFatalInExitHook -----")
+	var exitCodes []int
+	origExit := osExit
+	osExit = func(code int) {
+		exitCodes = append(exitCodes, code)
+	}
+	defer func() {
+		osExit = origExit
+		exiting.Store(false)
+		exitHooksLock.Lock()
+		exitHooks = nil
+		exitHooksLock.Unlock()
+	}()
+
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      logDir,
+		LogMod:          LOG_MODE_SERVER,
+		LogLineFormat:   "%level %msg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = logger.QuitMsgReader(3000)
+	}()
+	hookCalls := 0
+	RegisterExitHook(func() {
+		hookCalls++
+		logger.Fatal("退出钩子中的Fatal日志")
+	})
+
+	logger.Fatal("Fatal日志")
+	// 钩子中的Fatal日志应该落盘后直接终止程序，不应该再次执行退出钩子
+	if hookCalls != 1 {
+		t.Fatalf("退出钩子中输出Fatal日志时不应该递归执行退出钩子: %d", hookCalls)
+	}
+	if len(exitCodes) == 0 || exitCodes[0] != 1 {
+		t.Fatalf("退出钩子中的Fatal日志应该以状态码1终止程序: %v", exitCodes)
+	}
+	lines := strings.Split(strings.TrimSpace(readSingleLogFile(t, logDir, "zcgolog_")), "\n")
+	n := len(lines)
+	if n < 2 || !strings.HasSuffix(lines[n-2], "[FATAL] Fatal日志") || !strings.HasSuffix(lines[n-1], "[FATAL] 退出钩子中的Fatal日志") {
+		t.Fatalf("退出钩子中的Fatal日志应该在终止程序前落盘: %v", lines)
+	}
+}
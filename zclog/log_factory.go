@@ -18,58 +18,55 @@ import (
 	"io"
 	"log"
 	"os"
-	"sync"
 )
 
-var loggerLock sync.Mutex
-
 // 初始化zcgoLogger
 //  设置zcgoLogger的输出目标与日志前缀格式
-func initZcgoLogger() {
+func (l *Logger) initZcgoLogger() {
 	// 停止日志缓冲通道监听
 	// 防止应用程序在已经开启服务器模式后，刷新logger配置时与日志缓冲通道监听处理(readAndWriteMsg)中对日志文件的处理发生冲突。
-	err := QuitMsgReader(30000)
+	err := l.QuitMsgReader(30000)
 	if err != nil {
 		log.Panic(err)
 	}
 	// 上锁,确保logger操作的线程安全
-	loggerLock.Lock()
-	defer loggerLock.Unlock()
+	l.loggerLock.Lock()
+	defer l.loggerLock.Unlock()
 	// 临时切换zcgoLogger输出到控制台
-	zcgoLogger.SetOutput(os.Stdout)
+	l.stdLogger.SetOutput(os.Stdout)
 	// 设置日志前缀格式
-	zcgoLogger.SetFlags(log.Ldate | log.Ltime)
-	// 关闭当前日志文件
-	closeCurrentLogFile()
-	// 获取最新日志文件
-	logFilePath, todayYMD, err := GetLogFilePathAndYMDToday(zcgologConfig)
-	if err != nil {
-		// 未能成功获取日志文件时，直接输出到控制台
-		currentLogYMD = getYMDToday()
-		currentLogFile = nil
-		zcgoLogger.Println(err.Error())
-		return
+	l.stdLogger.SetFlags(l.loggerFlags())
+	// 关闭之前打开的日志输出目标
+	if err := l.closeSinks(); err != nil {
+		l.stdLogger.Println(err.Error())
 	}
-	if logFilePath == OS_OUT_STDOUT {
-		// LogFileDir为空时，直接输出到控制台
-		currentLogYMD = todayYMD
-		currentLogFile = nil
+	// 打开错误日志文件，已打开的错误日志文件会先关闭
+	if err := l.openErrorFile(); err != nil {
+		l.stdLogger.Println(err.Error())
+	}
+	if len(l.config.LogSinks) > 0 {
+		// 配置了日志输出目标时，日志只输出到这些目标，stdLogger保持输出到控制台
+		_ = l.fileWriter.Close()
+		l.openSinks()
 		return
 	}
-	currentLogYMD = todayYMD
-	currentLogFile, err = os.OpenFile(logFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
+	// 打开最新日志文件，已打开的日志文件会先关闭
+	opened, err := l.fileWriter.open()
 	if err != nil {
-		// 未能成功打开日志文件时，直接输出到控制台
-		currentLogFile = nil
-		zcgoLogger.Println(err.Error())
+		// 未能成功获取或打开日志文件时，直接输出到控制台
+		l.stdLogger.Println(err.Error())
+		return
+	}
+	if !opened {
+		// LogFileDir为空时，直接输出到控制台
 		return
 	}
-	if !zcgologConfig.LogForbidStdout {
+	if !l.config.LogForbidStdout {
 		// 日志同时输出到日志文件与控制台
-		multiWriter := io.MultiWriter(os.Stdout, currentLogFile)
-		zcgoLogger.SetOutput(multiWriter)
+		multiWriter := io.MultiWriter(os.Stdout, l.fileWriter)
+		l.stdLogger.SetOutput(multiWriter)
 	} else {
 		// 日志只输出到日志文件
-		zcgoLogger.SetOutput(currentLogFile)
+		l.stdLogger.SetOutput(l.fileWriter)
 	}
 }
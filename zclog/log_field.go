@@ -0,0 +1,255 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_field.go 结构化日志字段定义与处理
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Field 结构化日志字段
+type Field struct {
+	// 字段名
+	Key string
+	// 字段值
+	Value interface{}
+}
+
+// 无法识别的字段名，keysAndValues中key不是字符串或缺少对应value时使用
+const badFieldKey = "!BADKEY"
+
+// Any 任意类型的字段
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String 字符串字段
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int 整数字段
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 64位整数字段
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 浮点数字段
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool 布尔字段
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration 时间间隔字段
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Time 时间字段
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err 错误字段，字段名固定为"error"
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// 将交替的key/value参数转换为字段列表
+//
+//	参数可以是Field，也可以是交替出现的字符串key与任意类型value；
+//	key不是字符串时，该参数作为"!BADKEY"字段的值；
+//	末尾的key缺少对应value时，该key作为"!BADKEY"字段的值。
+func buildFields(keysAndValues []interface{}) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch kv := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, kv)
+		case string:
+			if i+1 >= len(keysAndValues) {
+				fields = append(fields, Field{Key: badFieldKey, Value: kv})
+				break
+			}
+			fields = append(fields, Field{Key: kv, Value: keysAndValues[i+1]})
+			i++
+		default:
+			fields = append(fields, Field{Key: badFieldKey, Value: kv})
+		}
+	}
+	return fields
+}
+
+// 以文本格式追加字段列表，格式: key1=value1 key2=value2
+func appendTextFields(buf []byte, fields []Field) []byte {
+	for i, field := range fields {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendTextValue(buf, field.Key)
+		buf = append(buf, '=')
+		buf = appendTextValue(buf, fieldValueString(field.Value))
+	}
+	return buf
+}
+
+// 以文本格式追加字段值，值为空或包含空白、引号、等号及控制字符时加引号
+func appendTextValue(buf []byte, s string) []byte {
+	if needQuote(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+func needQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取字段值的文本表示
+func fieldValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// 以JSON格式追加字段列表，每个字段前都会追加逗号，用于拼接在已有JSON对象的字段之后
+func appendJSONFields(buf []byte, fields []Field) []byte {
+	for _, field := range fields {
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, field.Key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, field.Value)
+	}
+	return buf
+}
+
+// 以JSON格式追加字段值
+func appendJSONValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		return appendJSONFloat(buf, v, 64)
+	case time.Time:
+		return appendJSONString(buf, v.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendJSONString(buf, v.String())
+	case error:
+		return appendJSONString(buf, v.Error())
+	case json.Marshaler:
+		// 交给json包处理，以便对MarshalJSON的输出做合法性检查与压缩
+		if data, err := json.Marshal(v); err == nil {
+			return append(buf, data...)
+		}
+		return appendJSONString(buf, fmt.Sprint(v))
+	case fmt.Stringer:
+		return appendJSONString(buf, v.String())
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			return append(buf, data...)
+		}
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+}
+
+// JSON不支持NaN与Inf，此时以字符串输出
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, bitSize))
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// With 基于默认Logger派生一个绑定了结构化字段的子Logger
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func With(keysAndValues ...interface{}) *Logger {
+	return defaultLogger.With(keysAndValues...)
+}
+
+// With 派生一个绑定了结构化字段的子Logger，子Logger输出的每条日志都会携带这些字段
+//
+//	子Logger与父Logger共享输出目标、日志缓冲通道与日志级别控制，只持有自己的字段列表；
+//	字段列表创建后不再修改，派生只需要复制一次字段切片，可以在每个请求中使用。
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	fields := buildFields(keysAndValues)
+	if len(fields) == 0 {
+		return l
+	}
+	boundFields := make([]Field, 0, len(l.fields)+len(fields))
+	boundFields = append(boundFields, l.fields...)
+	boundFields = append(boundFields, fields...)
+	return &Logger{logCore: l.logCore, fields: boundFields}
+}
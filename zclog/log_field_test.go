@@ -0,0 +1,175 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildFields(t *testing.T) {
+	fmt.Println("----- TestBuildFields -----")
+	fields := buildFields([]interface{}{"userId", 1001, String("requestId", "req-1"), 3.5, "dangling"})
+	expected := []Field{
+		{Key: "userId", Value: 1001},
+		{Key: "requestId", Value: "req-1"},
+		{Key: badFieldKey, Value: 3.5},
+		{Key: badFieldKey, Value: "dangling"},
+	}
+	if len(fields) != len(expected) {
+		t.Fatalf("字段数量不符合预期: %v", fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Fatalf("第%d个字段不符合预期: %v", i+1, fields[i])
+		}
+	}
+	if buildFields(nil) != nil {
+		t.Fatal("没有参数时应该返回nil")
+	}
+}
+
+func TestFieldsEncode(t *testing.T) {
+	fmt.Println("----- TestFieldsEncode -----")
+	msg := &logMsg{
+		pushTime: time.Date(2022, 5, 7, 16, 56, 39, 0, time.UTC),
+		logLevel: LOG_LEVEL_INFO,
+		callFile: "/home/zhaochun/zcgolog/zclog/log_test.go",
+		callLine: 56,
+		callFunc: "gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog",
+		logMsg:   "用户登录 100%",
+		fields: []Field{
+			Int("userId", 1001),
+			String("name", "zhao chun"),
+			Bool("admin", false),
+			Duration("cost", 1500*time.Millisecond),
+			Err(errors.New(`密码"错误"`)),
+			Any("roles", []string{"a", "b"}),
+		},
+	}
+
+	formatter, err := parseLineFormat("%level %msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(formatter.encode(nil, msg))
+	fmt.Println(line)
+	expected := `[ INFO] 用户登录 100% userId=1001 name="zhao chun" admin=false cost=1.5s error="密码\"错误\"" roles="[a b]"`
+	if line != expected {
+		t.Fatalf("文本格式字段输出不符合预期:\n%s\n%s", line, expected)
+	}
+
+	formatter, err = parseLineFormat("%msg {%fields} %level")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line = string(formatter.encode(nil, msg))
+	fmt.Println(line)
+	if !strings.HasPrefix(line, "用户登录 100% {userId=1001 ") || !strings.HasSuffix(line, "} [ INFO]") {
+		t.Fatalf("%%fields占位符输出不符合预期: %s", line)
+	}
+
+	jsonLine := jsonEncoder{}.encode(nil, msg)
+	fmt.Println(string(jsonLine))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(jsonLine, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "用户登录 100%" || entry["userId"] != float64(1001) || entry["name"] != "zhao chun" ||
+		entry["admin"] != false || entry["cost"] != "1.5s" || entry["error"] != `密码"错误"` {
+		t.Fatalf("JSON格式字段输出不符合预期: %s", jsonLine)
+	}
+	if roles, ok := entry["roles"].([]interface{}); !ok || len(roles) != 2 {
+		t.Fatalf("JSON格式数组字段输出不符合预期: %s", jsonLine)
+	}
+}
+
+func TestLocalLogw(t *testing.T) {
+	fmt.Println("----- TestLocalLogw -----")
+	InitLogger(&Config{LogLevelGlobal: LOG_LEVEL_DEBUG})
+	var buf bytes.Buffer
+	originalOutput := defaultLogger.stdLogger.Writer()
+	defaultLogger.stdLogger.SetOutput(&buf)
+	Debugw("调试", "step", 1)
+	Infow("登录成功", "userId", 1001, String("requestId", "req-1"))
+	Warnw("重试", Int("times", 3))
+	Errorw("登录失败", Err(errors.New("密码错误")))
+	logwWithCallerDepth("深度测试")
+	// 调用者深度为0时采用默认深度
+	InfowWithCallerDepth(0, "默认深度", "depth", 0)
+	defaultLogger.stdLogger.SetOutput(originalOutput)
+	fmt.Print(buf.String())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expectedSuffixes := []string{
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 调试 step=1",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 登录成功 userId=1001 requestId=req-1",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 重试 times=3",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 登录失败 error=密码错误",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 深度测试 depth=3",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 深度测试 depth=3",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 深度测试 depth=3",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 深度测试 depth=3",
+		"函数:gitee.com/zhaochuninhefei/zcgolog/zclog.TestLocalLogw 默认深度 depth=0",
+	}
+	if len(lines) != len(expectedSuffixes) {
+		t.Fatalf("日志行数不符合预期: %d", len(lines))
+	}
+	for i, suffix := range expectedSuffixes {
+		if !strings.HasSuffix(lines[i], suffix) {
+			t.Fatalf("第%d行日志不符合预期: %s", i+1, lines[i])
+		}
+	}
+}
+
+func logwWithCallerDepth(msg string) {
+	DebugwWithCallerDepth(3, msg, "depth", 3)
+	InfowWithCallerDepth(3, msg, "depth", 3)
+	WarnwWithCallerDepth(3, msg, "depth", 3)
+	ErrorwWithCallerDepth(3, msg, "depth", 3)
+}
+
+func TestFormattedMessage(t *testing.T) {
+	fmt.Println("----- TestFormattedMessage -----")
+	var buf bytes.Buffer
+	jsonSink, err := NewWriterSink(&buf, LOG_ENCODER_JSON, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordSink{}
+	logger, err := NewLogger(&Config{LogSinks: []SinkConfig{{Sink: jsonSink}, {Sink: sink}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 格式化输出接口没有参数时同样按格式化字符串处理，其他接口原样输出
+	logger.Infof("100%%")
+	logger.Infof("进度 %d%%", 50)
+	logger.Info("100%")
+	logger.Infow("100%%", "step", 1)
+	expected := []string{"100%", "进度 50%", "100%", "100%%"}
+	if len(sink.entries) != len(expected) {
+		t.Fatalf("日志条数不符合预期: %d", len(sink.entries))
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, msg := range expected {
+		if sink.entries[i].Message != msg {
+			t.Fatalf("第%d条日志的Message不符合预期: %s", i+1, sink.entries[i].Message)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(lines[i]), &doc); err != nil || doc["msg"] != msg {
+			t.Fatalf("第%d条JSON日志的msg不符合预期: %s", i+1, lines[i])
+		}
+	}
+}
@@ -0,0 +1,269 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_file_name.go 日志文件命名模板处理，负责解析Config.LogFileNamePattern，生成日志文件名以及识别已有的日志文件
+*/
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 日志文件命名模板占位符定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_FILE_NAME_PREFIX 日志文件名前缀，即配置LogFileNamePrefix
+	LOG_FILE_NAME_PREFIX = "%prefix"
+	// LOG_FILE_NAME_DATE 滚动周期标识，格式由配置LogFileDateLayout决定
+	LOG_FILE_NAME_DATE = "%date"
+	// LOG_FILE_NAME_HOSTNAME 主机名
+	LOG_FILE_NAME_HOSTNAME = "%hostname"
+	// LOG_FILE_NAME_PID 进程ID
+	LOG_FILE_NAME_PID = "%pid"
+	// LOG_FILE_NAME_SEQ 滚动周期内的日志文件序列号，宽度由配置LogFileSeqWidth决定，不足时补0
+	LOG_FILE_NAME_SEQ = "%seq"
+	// LOG_FILE_NAME_PATTERN_DEFAULT 默认日志文件命名模板，生成的日志文件名如: zcgolog_20220507_00001.log
+	LOG_FILE_NAME_PATTERN_DEFAULT = "%prefix_%date_%seq"
+)
+
+// 日志文件命名模板片段类型
+const (
+	fileNamePartText = iota
+	fileNamePartPrefix
+	fileNamePartDate
+	fileNamePartHostname
+	fileNamePartPid
+	fileNamePartSeq
+)
+
+// 占位符名称(不含%)与片段类型的对应关系
+var fileNameTokens = map[string]int{
+	LOG_FILE_NAME_PREFIX[1:]:   fileNamePartPrefix,
+	LOG_FILE_NAME_DATE[1:]:     fileNamePartDate,
+	LOG_FILE_NAME_HOSTNAME[1:]: fileNamePartHostname,
+	LOG_FILE_NAME_PID[1:]:      fileNamePartPid,
+	LOG_FILE_NAME_SEQ[1:]:      fileNamePartSeq,
+}
+
+// 日志文件命名模板片段
+type fileNamePart struct {
+	// 片段类型
+	kind int
+	// 字面文本，仅 fileNamePartText 使用
+	text string
+}
+
+// 编译后的日志文件命名模板
+type logFileNamer struct {
+	// 模板片段
+	parts []fileNamePart
+	// 日志文件名前缀
+	prefix string
+	// 日志文件扩展名，如 ".log"
+	ext string
+	// 序列号宽度
+	seqWidth int
+	// 日期格式，用于生成匹配任意滚动周期标识的正则表达式
+	dateLayout string
+}
+
+// 解析日志文件命名模板
+//
+//	支持的占位符: %prefix %date %hostname %pid %seq ，"%%" 表示字面的"%"；
+//	模板中必须包含且只能包含一个 %date 与 %seq ，不能包含路径分隔符。
+func parseLogFileNamePattern(logConfig *Config) (*logFileNamer, error) {
+	pattern := logConfig.LogFileNamePattern
+	if strings.ContainsAny(pattern, `/\`) {
+		return nil, fmt.Errorf("日志文件命名模板不能包含路径分隔符: %s", pattern)
+	}
+	// 日期格式中的字符原样出现在文件名中，包含路径分隔符时生成的文件不在日志目录下，保留策略与序列号计算都无法识别
+	if strings.ContainsAny(logConfig.LogFileDateLayout, `/\`) {
+		return nil, fmt.Errorf("滚动周期标识的日期格式不能包含路径分隔符: %s", logConfig.LogFileDateLayout)
+	}
+	if logConfig.LogFileSeqWidth < 1 || logConfig.LogFileSeqWidth > 9 {
+		return nil, fmt.Errorf("日志文件序列号宽度必须在1到9之间: %d", logConfig.LogFileSeqWidth)
+	}
+	if logConfig.LogFileExt == "" || strings.ContainsAny(logConfig.LogFileExt, `/\%`) {
+		return nil, fmt.Errorf("日志文件扩展名不能为空，且不能包含路径分隔符与%%: %s", logConfig.LogFileExt)
+	}
+	namer := &logFileNamer{
+		prefix:     logConfig.LogFileNamePrefix,
+		ext:        logConfig.LogFileExt,
+		seqWidth:   logConfig.LogFileSeqWidth,
+		dateLayout: getRotateDateLayout(logConfig),
+	}
+	var text []byte
+	counts := map[int]int{}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			text = append(text, pattern[i])
+			continue
+		}
+		if i+1 < len(pattern) && pattern[i+1] == '%' {
+			text = append(text, '%')
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(pattern) && isTokenLetter(pattern[j]) {
+			j++
+		}
+		kind, ok := fileNameTokens[pattern[i+1:j]]
+		if !ok {
+			return nil, fmt.Errorf("日志文件命名模板中存在无法识别的占位符: %s", pattern[i:j])
+		}
+		if len(text) > 0 {
+			namer.parts = append(namer.parts, fileNamePart{kind: fileNamePartText, text: string(text)})
+			text = nil
+		}
+		namer.parts = append(namer.parts, fileNamePart{kind: kind})
+		counts[kind]++
+		i = j - 1
+	}
+	if len(text) > 0 {
+		namer.parts = append(namer.parts, fileNamePart{kind: fileNamePartText, text: string(text)})
+	}
+	if counts[fileNamePartDate] != 1 || counts[fileNamePartSeq] != 1 {
+		return nil, fmt.Errorf("日志文件命名模板中必须包含且只能包含一个%s与%s: %s", LOG_FILE_NAME_DATE, LOG_FILE_NAME_SEQ, pattern)
+	}
+	return namer, nil
+}
+
+// 占位符名称只包含英文字母
+func isTokenLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// 当前滚动周期内允许的最大序列号
+func (n *logFileNamer) maxSeq() int {
+	maxSeq := 1
+	for i := 0; i < n.seqWidth; i++ {
+		maxSeq *= 10
+	}
+	return maxSeq - 1
+}
+
+// 生成日志文件名
+func (n *logFileNamer) fileName(period string, seq int) string {
+	var sb strings.Builder
+	for _, part := range n.parts {
+		switch part.kind {
+		case fileNamePartText:
+			sb.WriteString(part.text)
+		case fileNamePartPrefix:
+			sb.WriteString(n.prefix)
+		case fileNamePartDate:
+			sb.WriteString(period)
+		case fileNamePartHostname:
+			sb.WriteString(hostname)
+		case fileNamePartPid:
+			sb.WriteString(processID)
+		case fileNamePartSeq:
+			sb.WriteString(fmt.Sprintf("%0*d", n.seqWidth, seq))
+		}
+	}
+	sb.WriteString(n.ext)
+	return sb.String()
+}
+
+// 生成匹配日志文件名的正则表达式
+//
+//	period非空时只匹配当前进程在该滚动周期的日志文件；
+//	period为空时匹配本机任意滚动周期、任意进程ID的日志文件，用于保留策略。
+//	压缩后的日志文件同样匹配，第1个分组为序列号，第2个分组为压缩扩展名(未压缩时为空)。
+func (n *logFileNamer) regexp(period string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, part := range n.parts {
+		switch part.kind {
+		case fileNamePartText:
+			sb.WriteString(regexp.QuoteMeta(part.text))
+		case fileNamePartPrefix:
+			sb.WriteString(regexp.QuoteMeta(n.prefix))
+		case fileNamePartDate:
+			if period != "" {
+				sb.WriteString(regexp.QuoteMeta(period))
+			} else {
+				sb.WriteString(dateLayoutRegexp(n.dateLayout))
+			}
+		case fileNamePartHostname:
+			sb.WriteString(regexp.QuoteMeta(hostname))
+		case fileNamePartPid:
+			if period != "" {
+				sb.WriteString(regexp.QuoteMeta(processID))
+			} else {
+				sb.WriteString(`\d+`)
+			}
+		case fileNamePartSeq:
+			sb.WriteString(`(\d{` + strconv.Itoa(n.seqWidth) + `})`)
+		}
+	}
+	sb.WriteString(regexp.QuoteMeta(n.ext))
+	var exts []string
+	for _, ext := range compressedExts() {
+		if ext != "" {
+			exts = append(exts, regexp.QuoteMeta(ext))
+		}
+	}
+	sb.WriteString("(" + strings.Join(exts, "|") + ")?$")
+	return regexp.MustCompile(sb.String())
+}
+
+// go时间格式中的元素与匹配其输出的正则表达式，按长度优先匹配
+var dateLayoutElems = []struct {
+	elem    string
+	pattern string
+}{
+	{"2006", `\d{4}`},
+	{"January", `[A-Za-z]+`},
+	{"Monday", `[A-Za-z]+`},
+	{"Jan", `[A-Za-z]{3}`},
+	{"Mon", `[A-Za-z]{3}`},
+	{"01", `\d{2}`},
+	{"02", `\d{2}`},
+	{"06", `\d{2}`},
+	{"15", `\d{2}`},
+	{"03", `\d{2}`},
+	{"04", `\d{2}`},
+	{"05", `\d{2}`},
+	{"PM", `[AP]M`},
+}
+
+// 将go时间格式转换为匹配其输出的正则表达式，无法识别的数字按任意长度数字处理，其他字符按字面匹配
+func dateLayoutRegexp(layout string) string {
+	var sb strings.Builder
+	for i := 0; i < len(layout); {
+		matched := false
+		for _, e := range dateLayoutElems {
+			if strings.HasPrefix(layout[i:], e.elem) {
+				sb.WriteString(e.pattern)
+				i += len(e.elem)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if layout[i] >= '0' && layout[i] <= '9' {
+			sb.WriteString(`\d+`)
+		} else {
+			sb.WriteString(regexp.QuoteMeta(layout[i : i+1]))
+		}
+		i++
+	}
+	return sb.String()
+}
@@ -0,0 +1,150 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLogFileNamePattern(t *testing.T) {
+	fmt.Println("----- TestParseLogFileNamePattern -----")
+	invalidPatterns := []string{
+		"%prefix_%seq",
+		"%prefix_%date",
+		"%prefix_%date_%date_%seq",
+		"%prefix_%day_%seq",
+		"logs/%prefix_%date_%seq",
+	}
+	for _, pattern := range invalidPatterns {
+		config := newDefaultConfig()
+		config.LogFileNamePattern = pattern
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("日志文件命名模板 %q 应该校验失败", pattern)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	config := newDefaultConfig()
+	config.LogFileSeqWidth = 10
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("序列号宽度超出范围时应该校验失败")
+	}
+	for _, layout := range []string{"2006/01/02", `2006\01\02`} {
+		config = newDefaultConfig()
+		config.LogFileDateLayout = layout
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("日期格式 %q 包含路径分隔符时应该校验失败", layout)
+		}
+	}
+	// 未设置命名模板、序列号宽度与扩展名时采用默认值
+	config = newDefaultConfig()
+	config.LogFileNamePattern = ""
+	config.LogFileSeqWidth = 0
+	config.LogFileExt = ""
+	if _, err := CheckConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if config.LogFileNamePattern != LOG_FILE_NAME_PATTERN_DEFAULT || config.LogFileSeqWidth != 5 || config.LogFileExt != ".log" {
+		t.Fatalf("未设置的命名配置应该采用默认值: %+v", config)
+	}
+
+	config = newDefaultConfig()
+	namer, err := parseLogFileNamePattern(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := namer.fileName("20220507", 1); name != "zcgolog_20220507_00001.log" {
+		t.Fatalf("默认日志文件命名不符合预期: %s", name)
+	}
+	config.LogFileNamePattern = "%%%prefix_%date_%seq"
+	namer, err = parseLogFileNamePattern(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := namer.fileName("20220507", 1); name != "%zcgolog_20220507_00001.log" {
+		t.Fatalf("%%%%应该输出字面的%%: %s", name)
+	}
+}
+
+func TestLogFileNamePattern(t *testing.T) {
+	fmt.Println("----- TestLogFileNamePattern -----")
+	logDir := t.TempDir()
+	config := newDefaultConfig()
+	config.LogFileDir = logDir
+	config.LogFileNamePrefix = "app"
+	config.LogFileNamePattern = "%hostname-%prefix.%date.%pid.%seq"
+	config.LogFileDateLayout = "2006-01-02"
+	config.LogFileSeqWidth = 1
+	config.LogFileExt = ".txt"
+	period := getRotatePeriod(config, time.Now())
+	if period != time.Now().Format("2006-01-02") {
+		t.Fatalf("滚动周期标识应该采用配置的日期格式: %s", period)
+	}
+
+	// 其他进程的日志文件不参与当前进程的序列号计算，但会被保留策略识别
+	otherPidFile := fmt.Sprintf("%s-app.%s.%d.3.txt", hostname, period, os.Getpid()+1)
+	if err := os.WriteFile(path.Join(logDir, otherPidFile), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	logFilePath, _, err := GetLogFilePathAndYMDToday(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf("%s-app.%s.%s.1.txt", hostname, period, processID)
+	if filepath.Base(logFilePath) != expected {
+		t.Fatalf("日志文件名不符合预期: %s, 预期: %s", filepath.Base(logFilePath), expected)
+	}
+	logFiles, err := listLogFiles(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) != 2 {
+		t.Fatalf("保留策略应该识别本机所有进程的日志文件: %v", logFiles)
+	}
+	namer, _ := parseLogFileNamePattern(config)
+	if namer.regexp("").MatchString("otherhost-app." + period + ".1.1.txt") {
+		t.Fatal("保留策略不应该识别其他主机的日志文件")
+	}
+
+	// 序列号宽度为1时，每个滚动周期最多9个日志文件
+	for i := 2; i <= 9; i++ {
+		name := fmt.Sprintf("%s-app.%s.%s.%d.txt.gz", hostname, period, processID, i)
+		if err := os.WriteFile(path.Join(logDir, name), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := GetLogFilePathAndYMDToday(config); err == nil {
+		t.Fatal("日志文件数量超过序列号宽度允许的上限时应该返回错误")
+	}
+}
+
+func TestDateLayoutRegexp(t *testing.T) {
+	fmt.Println("----- TestDateLayoutRegexp -----")
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"20060102", `\d{4}\d{2}\d{2}`},
+		{"2006010215", `\d{4}\d{2}\d{2}\d{2}`},
+		{"2006-01-02", `\d{4}-\d{2}-\d{2}`},
+		{"Jan_2_2006", `[A-Za-z]{3}_\d+_\d{4}`},
+	}
+	for _, tt := range tests {
+		if got := dateLayoutRegexp(tt.layout); got != tt.want {
+			t.Fatalf("日期格式 %s 转换的正则表达式不符合预期: %s, 预期: %s", tt.layout, got, tt.want)
+		}
+	}
+}
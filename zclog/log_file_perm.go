@@ -0,0 +1,126 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_file_perm.go 日志文件与日志目录的权限及属组处理
+*/
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// 日志文件与日志目录的默认权限
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_FILE_MODE_DEFAULT 日志文件默认权限
+	LOG_FILE_MODE_DEFAULT os.FileMode = 0644
+	// LOG_DIR_MODE_DEFAULT 日志目录默认权限
+	LOG_DIR_MODE_DEFAULT os.FileMode = 0755
+)
+
+// 检查日志文件与日志目录的权限及属组配置
+//
+//	权限只能包含权限位，且日志文件权限需要包含属主写权限，日志目录权限需要包含属主读写执行权限；
+//	配置了属组时，属组必须存在。
+func checkLogFilePermConfig(logConfig *Config) error {
+	if logConfig.LogFileMode&^os.ModePerm != 0 || logConfig.LogFileMode&0200 == 0 {
+		return fmt.Errorf("日志文件权限不合法: %04o，只能包含权限位且需要包含属主写权限", uint32(logConfig.LogFileMode))
+	}
+	if logConfig.LogDirMode&^os.ModePerm != 0 || logConfig.LogDirMode&0700 != 0700 {
+		return fmt.Errorf("日志目录权限不合法: %04o，只能包含权限位且需要包含属主读写执行权限", uint32(logConfig.LogDirMode))
+	}
+	if _, err := lookupLogFileGid(logConfig.LogFileGroup); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 获取日志文件属组对应的gid
+//
+//	group可以是组名或数字形式的gid，为空时返回-1，表示不修改属组。
+func lookupLogFileGid(group string) (int, error) {
+	if group == "" {
+		return -1, nil
+	}
+	if gid, err := strconv.Atoi(group); err == nil {
+		if gid < 0 {
+			return -1, fmt.Errorf("日志文件属组不合法: %s", group)
+		}
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return -1, fmt.Errorf("日志文件属组不存在: %s, %w", group, err)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return -1, fmt.Errorf("日志文件属组 %s 的gid无法识别: %s", group, g.Gid)
+	}
+	return gid, nil
+}
+
+// 为zclog创建的文件或目录设置配置的权限与属组
+//
+//	创建文件时指定的权限会受到umask影响，因此创建后再显式设置一次权限。
+func applyLogFilePerm(logConfig *Config, filePath string, mode os.FileMode) error {
+	if err := os.Chmod(filePath, mode); err != nil {
+		return fmt.Errorf("设置 %s 的权限为 %04o 失败: %w", filePath, uint32(mode), err)
+	}
+	gid, err := lookupLogFileGid(logConfig.LogFileGroup)
+	if err != nil {
+		return err
+	}
+	if gid >= 0 {
+		if err := os.Chown(filePath, -1, gid); err != nil {
+			return fmt.Errorf("设置 %s 的属组为 %s 失败: %w", filePath, logConfig.LogFileGroup, err)
+		}
+	}
+	return nil
+}
+
+// 确保日志目录存在，日志目录由zclog创建时设置配置的权限与属组
+//
+//	已经存在的日志目录不修改其权限与属组。
+func ensureLogFileDir(logConfig *Config) error {
+	dirStat, err := os.Stat(logConfig.LogFileDir)
+	if err == nil {
+		if !dirStat.IsDir() {
+			return fmt.Errorf("日志目录 %s 已存在且不是目录", logConfig.LogFileDir)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(logConfig.LogFileDir, logConfig.LogDirMode); err != nil {
+		return fmt.Errorf("创建日志目录 %s 失败: %w", logConfig.LogFileDir, err)
+	}
+	return applyLogFilePerm(logConfig, logConfig.LogFileDir, logConfig.LogDirMode)
+}
+
+// 创建日志文件并设置配置的权限与属组，文件已存在时清空内容
+func createLogFile(logConfig *Config, filePath string, flag int) (*os.File, error) {
+	file, err := os.OpenFile(filePath, flag|os.O_CREATE|os.O_TRUNC, logConfig.LogFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("创建日志文件 %s 失败: %w", filePath, err)
+	}
+	if err := applyLogFilePerm(logConfig, filePath, logConfig.LogFileMode); err != nil {
+		_ = file.Close()
+		_ = os.Remove(filePath)
+		return nil, err
+	}
+	return file, nil
+}
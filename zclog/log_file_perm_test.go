@@ -0,0 +1,111 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestCheckLogFilePermConfig(t *testing.T) {
+	fmt.Println("----- TestCheckLogFilePermConfig -----")
+	invalidConfigs := []func(config *Config){
+		func(config *Config) { config.LogFileMode = 0444 },
+		func(config *Config) { config.LogFileMode = os.ModeSetuid | 0644 },
+		func(config *Config) { config.LogDirMode = 0600 },
+		func(config *Config) { config.LogFileGroup = "zclog-no-such-group" },
+		func(config *Config) { config.LogFileGroup = "-1" },
+	}
+	for i, setConfig := range invalidConfigs {
+		config := newDefaultConfig()
+		setConfig(config)
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("第%d个权限配置应该校验失败", i+1)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	config := newDefaultConfig()
+	config.LogFileGroup = strconv.Itoa(os.Getgid())
+	if _, err := CheckConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	// 未设置权限时采用默认权限
+	config.LogFileMode = 0
+	config.LogDirMode = 0
+	if _, err := CheckConfig(config); err != nil || config.LogFileMode != LOG_FILE_MODE_DEFAULT || config.LogDirMode != LOG_DIR_MODE_DEFAULT {
+		t.Fatalf("未设置权限时应该采用默认权限: %04o, %04o, %v", uint32(config.LogFileMode), uint32(config.LogDirMode), err)
+	}
+}
+
+func TestLogFilePerm(t *testing.T) {
+	fmt.Println("----- TestLogFilePerm -----")
+	// 设置umask，确认配置的权限不受umask影响
+	oldMask := syscall.Umask(0077)
+	defer syscall.Umask(oldMask)
+	logDir := path.Join(t.TempDir(), "app", "logs")
+	gid := os.Getgid()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      logDir,
+		LogMod:          LOG_MODE_LOCAL,
+		LogFileMode:     0640,
+		LogDirMode:      0750,
+		LogFileGroup:    strconv.Itoa(gid),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("测试日志文件权限")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkPerm := func(filePath string, mode os.FileMode) {
+		fileStat, err := os.Stat(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fileStat.Mode().Perm() != mode {
+			t.Fatalf("%s 的权限不符合预期: %04o, 预期: %04o", filePath, fileStat.Mode().Perm(), mode)
+		}
+		if int(fileStat.Sys().(*syscall.Stat_t).Gid) != gid {
+			t.Fatalf("%s 的属组不符合预期: %d, 预期: %d", filePath, fileStat.Sys().(*syscall.Stat_t).Gid, gid)
+		}
+	}
+	checkPerm(logDir, 0750)
+	logFiles, err := listLogFiles(logger.config)
+	if err != nil || len(logFiles) != 1 {
+		t.Fatalf("日志文件数量不符合预期: %v, %v", logFiles, err)
+	}
+	logFilePath := path.Join(logDir, logFiles[0].name)
+	checkPerm(logFilePath, 0640)
+
+	// 压缩后的日志文件同样采用配置的权限
+	compressor, _ := getCompressor(LOG_COMPRESSION_GZIP)
+	if err := compressLogFile(logger.config, compressor, logFilePath); err != nil {
+		t.Fatal(err)
+	}
+	checkPerm(logFilePath+compressor.Ext(), 0640)
+
+	// 日志目录路径上存在普通文件时返回明确的错误
+	config := newDefaultConfig()
+	config.LogFileDir = logFilePath + compressor.Ext()
+	if _, _, err := GetLogFilePathAndYMDToday(config); err == nil {
+		t.Fatal("日志目录路径上存在普通文件时应该返回错误")
+	} else {
+		fmt.Println(err)
+	}
+}
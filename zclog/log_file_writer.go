@@ -0,0 +1,251 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_file_writer.go 支持滚动的日志文件输出
+*/
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 日志文件输出，负责日志文件的打开、滚动、落盘与关闭
+//
+//	每次写入前检查是否需要滚动，本地模式与服务器模式采用相同的滚动、命名与保留策略。
+//	滚动检查只比较内存中的已写入字节数与预先计算的下次滚动时间，只在打开与滚动日志文件时访问文件系统。
+//	写入与滚动通过排他锁保证线程安全，本地模式下多个goroutine同步输出日志时也不会冲突。
+//	服务器模式下日志先写入缓冲区，由日志缓冲通道监听在缓冲区已满、通道已空或到达刷新间隔时批量写入日志文件；
+//	本地模式下不使用缓冲区，每条日志直接写入日志文件。
+type logFileWriter struct {
+	// 日志配置
+	config *Config
+
+	// 上锁,确保日志文件写入与滚动的线程安全
+	lock sync.Mutex
+	// 当前日志文件，未打开或已关闭时为nil
+	file *os.File
+	// 当前日志文件的写入缓冲区，仅在服务器模式下使用，不使用时为nil
+	buf *bufio.Writer
+	// 当前日志文件已写入的字节数，打开日志文件时以文件大小初始化
+	size int64
+	// 下次按时间滚动的时间，即当前滚动周期的结束时间
+	nextRotateTime time.Time
+	// 最近打开的日志文件名，日志文件关闭后仍保留，清理历史日志文件时不会删除该文件
+	lastFileName string
+
+	// 历史日志文件清理任务是否在执行
+	janitorRunning atomic.Bool
+	// 是否有待执行的历史日志文件清理请求
+	janitorPending atomic.Bool
+	// 待压缩日志文件列表使用的锁
+	compressLock sync.Mutex
+	// 待压缩的滚动后日志文件，由历史日志文件清理任务在清理前压缩
+	compressQueue []string
+	// 日志文件滚动后启动的后台任务，Shutdown时等待其结束；同一Logger的多个日志文件输出共享
+	bgTasks *sync.WaitGroup
+}
+
+// 创建日志文件输出，此时尚未打开日志文件
+func newLogFileWriter(config *Config) *logFileWriter {
+	return &logFileWriter{config: config, bgTasks: &sync.WaitGroup{}}
+}
+
+// 打开当前滚动周期的最新日志文件，已打开的日志文件会先关闭
+//
+//	LogFileDir为空时不打开日志文件，返回false；打开失败时返回错误。
+func (w *logFileWriter) open() (bool, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_ = w.closeFile()
+	return w.openFile()
+}
+
+// 打开当前滚动周期的最新日志文件，需要在持有lock时调用
+func (w *logFileWriter) openFile() (bool, error) {
+	w.nextRotateTime = getNextRotateTime(w.config, time.Now())
+	w.size = 0
+	logFilePath, _, err := GetLogFilePathAndYMDToday(w.config)
+	if err != nil {
+		return false, err
+	}
+	if logFilePath == OS_OUT_STDOUT {
+		// LogFileDir为空时，只能输出到控制台
+		return false, nil
+	}
+	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, w.config.LogFileMode)
+	if err != nil {
+		return false, err
+	}
+	// 继续写入已有日志文件时，以文件大小作为已写入字节数
+	fileStat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return false, err
+	}
+	w.file = file
+	w.lastFileName = path.Base(file.Name())
+	w.size = fileStat.Size()
+	if w.config.LogMod == LOG_MODE_SERVER {
+		w.buf = bufio.NewWriterSize(file, w.config.LogFileBufferSizeK*1024)
+	}
+	// 更新指向当前日志文件的软链接，失败时不影响日志输出
+	if w.config.LogFileSymlink {
+		if err := updateLogFileSymlink(w.config, logFilePath); err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "zclog 更新日志文件软链接发生错误: %s\n", err)
+		}
+	}
+	return true, nil
+}
+
+// 重新打开日志文件，没有打开日志文件时不做处理
+func (w *logFileWriter) reopen() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	closeErr := w.closeFile()
+	_, openErr := w.openFile()
+	return errors.Join(closeErr, openErr)
+}
+
+// 将缓冲区中的日志写入日志文件后关闭，需要在持有lock时调用
+func (w *logFileWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	flushErr := w.flushBuffer()
+	closeErr := w.file.Close()
+	w.file = nil
+	w.buf = nil
+	return errors.Join(flushErr, closeErr)
+}
+
+// 将缓冲区中的日志写入日志文件，需要在持有lock时调用
+func (w *logFileWriter) flushBuffer() error {
+	if w.buf == nil {
+		return nil
+	}
+	return w.buf.Flush()
+}
+
+// Write 写入一条日志，写入前检查日志文件是否需要滚动
+//
+//	日志文件已关闭或滚动失败时，日志输出到控制台(LogForbidStdout为false时已经输出到控制台，不再重复输出)。
+func (w *logFileWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file != nil && w.needRotate() {
+		w.rotate()
+	}
+	if w.file == nil {
+		if w.config.LogForbidStdout {
+			return os.Stdout.Write(p)
+		}
+		return len(p), nil
+	}
+	var n int
+	var err error
+	if w.buf != nil {
+		n, err = w.buf.Write(p)
+	} else {
+		n, err = w.file.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+// 检查日志文件是否需要滚动，需要在持有lock时调用
+func (w *logFileWriter) needRotate() bool {
+	// 当前日志文件大小超过上限或到达下次滚动时间时，做日志文件滚动处理
+	return w.size >= int64(w.config.LogFileMaxSizeM)*1024*1024 || !time.Now().Before(w.nextRotateTime)
+}
+
+// 日志文件滚动处理，需要在持有lock时调用
+func (w *logFileWriter) rotate() {
+	prevLogFilePath := w.file.Name()
+	_ = w.closeFile()
+	if _, err := w.openFile(); err != nil {
+		// 获取最新日志文件失败时，直接向控制台输出
+		_, _ = fmt.Fprintf(os.Stdout, "zclog 日志文件滚动发生错误: %s\n", err)
+		return
+	}
+	// 压缩滚动前的日志文件
+	if w.file != nil && w.file.Name() != prevLogFilePath {
+		w.queueLogFileCompression(prevLogFilePath)
+	}
+	// 压缩滚动前的日志文件后，按保留策略清理历史日志文件
+	w.startLogFileJanitor()
+}
+
+// Flush 将缓冲区中的日志写入日志文件
+func (w *logFileWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.flushBuffer()
+}
+
+// Sync 将缓冲区中的日志写入日志文件并落盘
+func (w *logFileWriter) Sync() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	if err := w.flushBuffer(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close 将缓冲区中的日志写入日志文件并落盘后关闭
+func (w *logFileWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	flushErr := w.flushBuffer()
+	syncErr := w.file.Sync()
+	return errors.Join(flushErr, syncErr, w.closeFile())
+}
+
+// 当前日志文件是否已打开
+func (w *logFileWriter) isOpen() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.file != nil
+}
+
+// 获取当前日志文件名，没有日志文件时返回空字符串
+func (w *logFileWriter) fileName() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return ""
+	}
+	return path.Base(w.file.Name())
+}
+
+// 获取最近打开的日志文件名，日志文件关闭后仍返回关闭前的日志文件名
+func (w *logFileWriter) lastOpenedFileName() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.lastFileName
+}
@@ -0,0 +1,209 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocalModeRotation(t *testing.T) {
+	fmt.Println("----- TestLocalModeRotation -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      logDir,
+		LogMod:          LOG_MODE_LOCAL,
+		LogFileMaxSizeM: 1,
+		LogLineFormat:   "%level %msg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("测试日志", 64)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				logger.Info(msg)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	logFiles, err := listLogFiles(logger.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) < 3 {
+		t.Fatalf("本地模式下日志文件应该按大小滚动: %v", logFiles)
+	}
+	lineCount := 0
+	for i, f := range logFiles {
+		content, err := os.ReadFile(path.Join(logDir, f.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		lineCount += len(lines)
+		for _, line := range lines {
+			if !strings.HasSuffix(line, "INFO] "+msg) {
+				t.Fatalf("并发输出的日志行不完整: %s", line)
+			}
+		}
+		// 除最新的日志文件外，滚动时日志文件大小刚好达到上限
+		if i < len(logFiles)-1 && (f.size < 1024*1024 || f.size > 1024*1024+int64(len(lines[0]))+1) {
+			t.Fatalf("滚动后的日志文件大小不符合预期: %s %d", f.name, f.size)
+		}
+	}
+	if lineCount != 4000 {
+		t.Fatalf("日志行数不符合预期: %d", lineCount)
+	}
+}
+
+func TestLogFileWriterSizeCounter(t *testing.T) {
+	fmt.Println("----- TestLogFileWriterSizeCounter -----")
+	config := newDefaultConfig()
+	config.LogFileDir = t.TempDir()
+	config.LogForbidStdout = true
+	w := newLogFileWriter(config)
+	if _, err := w.open(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write([]byte(fmt.Sprintf("测试日志: %d\n", i+1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fileStat, err := os.Stat(w.file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.size != fileStat.Size() {
+		t.Fatalf("已写入字节数与日志文件大小不一致: %d, %d", w.size, fileStat.Size())
+	}
+	// 继续写入已有日志文件时，以文件大小初始化已写入字节数
+	if _, err := w.open(); err != nil {
+		t.Fatal(err)
+	}
+	if w.size != fileStat.Size() {
+		t.Fatalf("重新打开日志文件后已写入字节数不符合预期: %d, %d", w.size, fileStat.Size())
+	}
+	if !w.nextRotateTime.After(time.Now()) {
+		t.Fatal("下次滚动时间应该晚于当前时间")
+	}
+	// 到达下次滚动时间时，写入前做滚动处理
+	w.nextRotateTime = time.Now().Add(-time.Second)
+	if _, err := w.Write([]byte("滚动后的日志\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !w.nextRotateTime.After(time.Now()) {
+		t.Fatal("滚动后应该重新计算下次滚动时间")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogFileWriterBuffer(t *testing.T) {
+	fmt.Println("----- TestLogFileWriterBuffer -----")
+	config := newDefaultConfig()
+	config.LogFileDir = t.TempDir()
+	config.LogForbidStdout = true
+	config.LogMod = LOG_MODE_SERVER
+	config.LogFileBufferSizeK = 1
+	w := newLogFileWriter(config)
+	if _, err := w.open(); err != nil {
+		t.Fatal(err)
+	}
+	fileSize := func() int64 {
+		fileStat, err := os.Stat(w.file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fileStat.Size()
+	}
+	line := []byte("测试日志测试日志\n")
+	for i := 0; i < 10; i++ {
+		_, _ = w.Write(line)
+	}
+	// 缓冲区未满时不写入日志文件
+	if fileSize() != 0 {
+		t.Fatal("服务器模式下日志应该先写入缓冲区")
+	}
+	// 缓冲区已满时批量写入日志文件
+	for i := 0; i < 100; i++ {
+		_, _ = w.Write(line)
+	}
+	if size := fileSize(); size == 0 || size%int64(1024) != 0 {
+		t.Fatalf("缓冲区已满时应该批量写入日志文件: %d", size)
+	}
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if fileSize() != int64(110*len(line)) || w.size != int64(110*len(line)) {
+		t.Fatal("Sync应该将缓冲区中的日志写入日志文件")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未设置缓冲区大小与刷新间隔时采用默认值，小于0时校验失败
+	config = newDefaultConfig()
+	config.LogFileBufferSizeK = 0
+	config.LogFileFlushIntervalMilliSec = 0
+	if _, err := CheckConfig(config); err != nil || config.LogFileBufferSizeK != 256 || config.LogFileFlushIntervalMilliSec != 1000 {
+		t.Fatalf("未设置缓冲区大小与刷新间隔时应该采用默认值: %d, %d, %v", config.LogFileBufferSizeK, config.LogFileFlushIntervalMilliSec, err)
+	}
+	config.LogFileBufferSizeK = -1
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("缓冲区大小小于0时应该校验失败")
+	}
+}
+
+func TestServerModeFlush(t *testing.T) {
+	fmt.Println("----- TestServerModeFlush -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:              true,
+		LogFileDir:                   logDir,
+		LogMod:                       LOG_MODE_SERVER,
+		LogFileFlushIntervalMilliSec: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = logger.QuitMsgReader(3000)
+	}()
+	for i := 0; i < 100; i++ {
+		logger.Infof("批量写入的日志: %d", i+1)
+	}
+	// 日志缓冲通道已空或到达刷新间隔时，缓冲区中的日志写入日志文件
+	for i := 0; i < 20; i++ {
+		if strings.Count(readSingleLogFile(t, logDir, "zcgolog_"), "批量写入的日志") == 100 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("日志缓冲通道已空后缓冲区中的日志应该写入日志文件")
+}
@@ -0,0 +1,243 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_format.go 日志行格式模板处理，负责解析Config.LogLineFormat并按模板渲染日志行
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// 日志行格式占位符定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_FORMAT_LEVEL 日志级别，如"[ INFO]"
+	LOG_FORMAT_LEVEL = "%level"
+	// LOG_FORMAT_PUSH_TIME 调用方请求输出日志的时间，格式: yyyy-MM-dd HH:mm:ss
+	LOG_FORMAT_PUSH_TIME = "%pushTime"
+	// LOG_FORMAT_DATETIME 同 %pushTime
+	LOG_FORMAT_DATETIME = "%datetime"
+	// LOG_FORMAT_FILE 代码文件完整路径
+	LOG_FORMAT_FILE = "%file"
+	// LOG_FORMAT_SHORT_FILE 代码文件名，不含目录
+	LOG_FORMAT_SHORT_FILE = "%shortFile"
+	// LOG_FORMAT_LINE 代码行数
+	LOG_FORMAT_LINE = "%line"
+	// LOG_FORMAT_CALL_FUNC 调用方函数包路径
+	LOG_FORMAT_CALL_FUNC = "%callFunc"
+	// LOG_FORMAT_FUNC 同 %callFunc
+	LOG_FORMAT_FUNC = "%func"
+	// LOG_FORMAT_MSG 日志内容
+	LOG_FORMAT_MSG = "%msg"
+	// LOG_FORMAT_PID 进程ID
+	LOG_FORMAT_PID = "%pid"
+	// LOG_FORMAT_GOID 调用方goroutine ID
+	LOG_FORMAT_GOID = "%goid"
+	// LOG_FORMAT_HOSTNAME 主机名
+	LOG_FORMAT_HOSTNAME = "%hostname"
+	// LOG_FORMAT_FIELDS 结构化日志字段，格式: key1=value1 key2=value2 ；模板中没有该占位符时，字段追加在日志行末尾
+	LOG_FORMAT_FIELDS = "%fields"
+	// LOG_LINE_FORMAT_DEFAULT 默认日志行格式
+	LOG_LINE_FORMAT_DEFAULT = "%level 时间:%pushTime 代码:%file %line 函数:%callFunc %msg"
+)
+
+// 日志行格式片段类型
+const (
+	formatPartText = iota
+	formatPartLevel
+	formatPartPushTime
+	formatPartFile
+	formatPartShortFile
+	formatPartLine
+	formatPartCallFunc
+	formatPartMsg
+	formatPartPid
+	formatPartGoid
+	formatPartHostname
+	formatPartFields
+)
+
+// 占位符名称(不含%)与片段类型的对应关系
+var lineFormatTokens = map[string]int{
+	LOG_FORMAT_LEVEL[1:]:      formatPartLevel,
+	LOG_FORMAT_PUSH_TIME[1:]:  formatPartPushTime,
+	LOG_FORMAT_DATETIME[1:]:   formatPartPushTime,
+	LOG_FORMAT_FILE[1:]:       formatPartFile,
+	LOG_FORMAT_SHORT_FILE[1:]: formatPartShortFile,
+	LOG_FORMAT_LINE[1:]:       formatPartLine,
+	LOG_FORMAT_CALL_FUNC[1:]:  formatPartCallFunc,
+	LOG_FORMAT_FUNC[1:]:       formatPartCallFunc,
+	LOG_FORMAT_MSG[1:]:        formatPartMsg,
+	LOG_FORMAT_PID[1:]:        formatPartPid,
+	LOG_FORMAT_GOID[1:]:       formatPartGoid,
+	LOG_FORMAT_HOSTNAME[1:]:   formatPartHostname,
+	LOG_FORMAT_FIELDS[1:]:     formatPartFields,
+}
+
+// 日志行格式片段
+type lineFormatPart struct {
+	// 片段类型
+	kind int
+	// 字面文本，仅 formatPartText 使用
+	text string
+}
+
+// 编译后的日志行格式模板
+type lineFormatter struct {
+	// 模板片段
+	parts []lineFormatPart
+	// 模板是否使用了%goid，只有使用时才在调用方获取goroutine ID
+	needGoid bool
+	// 模板是否使用了%fields
+	hasFields bool
+}
+
+// 当前进程ID与主机名，进程内不会变化，启动时获取一次即可
+var (
+	processID = strconv.Itoa(os.Getpid())
+	hostname  = getHostname()
+)
+
+func getHostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
+
+// CheckLogLineFormat 检查日志行格式是否合法
+//
+//	支持的占位符: %level %pushTime(%datetime) %file %shortFile %line %callFunc(%func) %msg %pid %goid %hostname %fields ;
+//	"%%" 表示字面的"%"；
+//	格式中必须包含 %msg 。
+func CheckLogLineFormat(format string) error {
+	_, err := parseLineFormat(format)
+	return err
+}
+
+// 解析日志行格式，生成日志行格式模板
+func parseLineFormat(format string) (*lineFormatter, error) {
+	formatter := &lineFormatter{}
+	var text []byte
+	hasMsg := false
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			text = append(text, c)
+			continue
+		}
+		// "%%" 转义为字面的"%"
+		if i+1 < len(format) && format[i+1] == '%' {
+			text = append(text, '%')
+			i++
+			continue
+		}
+		// 读取%之后连续的ASCII字母作为占位符名称
+		j := i + 1
+		for j < len(format) && isASCIILetter(format[j]) {
+			j++
+		}
+		name := format[i+1 : j]
+		kind, ok := lineFormatTokens[name]
+		if !ok {
+			return nil, fmt.Errorf("日志格式 %q 中存在无法识别的占位符: %q", format, "%"+name)
+		}
+		if len(text) > 0 {
+			formatter.parts = append(formatter.parts, lineFormatPart{kind: formatPartText, text: string(text)})
+			text = nil
+		}
+		formatter.parts = append(formatter.parts, lineFormatPart{kind: kind})
+		switch kind {
+		case formatPartMsg:
+			hasMsg = true
+		case formatPartGoid:
+			formatter.needGoid = true
+		case formatPartFields:
+			formatter.hasFields = true
+		}
+		i = j - 1
+	}
+	if len(text) > 0 {
+		formatter.parts = append(formatter.parts, lineFormatPart{kind: formatPartText, text: string(text)})
+	}
+	if !hasMsg {
+		return nil, fmt.Errorf("日志格式 %q 中缺少占位符: %s", format, LOG_FORMAT_MSG)
+	}
+	return formatter, nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// 按模板渲染日志行，追加到buf后返回
+func (f *lineFormatter) format(buf []byte, msg *logMsg) []byte {
+	for _, part := range f.parts {
+		switch part.kind {
+		case formatPartText:
+			buf = append(buf, part.text...)
+		case formatPartLevel:
+			buf = append(buf, LogLevels[msg.logLevel]...)
+		case formatPartPushTime:
+			buf = msg.pushTime.AppendFormat(buf, LOG_TIME_FORMAT_YMDHMS)
+		case formatPartFile:
+			buf = append(buf, msg.callFile...)
+		case formatPartShortFile:
+			buf = append(buf, filepath.Base(msg.callFile)...)
+		case formatPartLine:
+			buf = strconv.AppendInt(buf, int64(msg.callLine), 10)
+		case formatPartCallFunc:
+			buf = append(buf, msg.callFunc...)
+		case formatPartMsg:
+			buf = msg.appendMessage(buf)
+		case formatPartPid:
+			buf = append(buf, processID...)
+		case formatPartGoid:
+			buf = strconv.AppendUint(buf, msg.goid, 10)
+		case formatPartHostname:
+			buf = append(buf, hostname...)
+		case formatPartFields:
+			buf = appendTextFields(buf, msg.fields)
+		}
+	}
+	// 模板中没有%fields时，字段追加在日志行末尾
+	if !f.hasFields && len(msg.fields) > 0 {
+		buf = append(buf, ' ')
+		buf = appendTextFields(buf, msg.fields)
+	}
+	return buf
+}
+
+// 获取当前goroutine ID
+//
+//	runtime.Stack输出的第一行格式为"goroutine 123 [running]:"
+func getGoroutineID() uint64 {
+	var stack [64]byte
+	n := runtime.Stack(stack[:], false)
+	idField := bytes.TrimPrefix(stack[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(idField, ' '); i >= 0 {
+		idField = idField[:i]
+	}
+	id, err := strconv.ParseUint(string(idField), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,119 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLineFormat(t *testing.T) {
+	fmt.Println("----- TestParseLineFormat -----")
+	validFormats := []string{
+		LOG_LINE_FORMAT_DEFAULT,
+		"%msg",
+		"%datetime %level %file %line %func %msg",
+		"[%pid-%goid@%hostname] %shortFile:%line 100%% %msg",
+	}
+	for _, format := range validFormats {
+		if err := CheckLogLineFormat(format); err != nil {
+			t.Fatalf("日志格式 %q 应该合法: %s", format, err)
+		}
+	}
+	invalidFormats := []string{
+		"",
+		"%level %file",
+		"%level %mesage",
+		"%msg %",
+	}
+	for _, format := range invalidFormats {
+		err := CheckLogLineFormat(format)
+		if err == nil {
+			t.Fatalf("日志格式 %q 应该不合法", format)
+		}
+		fmt.Println(err)
+	}
+}
+
+func TestFormatLogLine(t *testing.T) {
+	fmt.Println("----- TestFormatLogLine -----")
+	pushTime := time.Date(2022, 5, 7, 16, 56, 39, 0, time.Local)
+	msg := &logMsg{
+		pushTime:  pushTime,
+		logLevel:  LOG_LEVEL_WARNING,
+		callFile:  "/home/zhaochun/zcgolog/zclog/log_test.go",
+		callLine:  56,
+		callFunc:  "gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog",
+		logMsg:    "测试日志: %d",
+		formatted: true,
+		logParams: []interface{}{1},
+		goid:      7,
+	}
+
+	formatter, err := parseLineFormat(LOG_LINE_FORMAT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(formatter.format(nil, msg))
+	fmt.Println(line)
+	expected := "[ WARN] 时间:2022-05-07 16:56:39 代码:/home/zhaochun/zcgolog/zclog/log_test.go 56 函数:gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog 测试日志: 1"
+	if line != expected {
+		t.Fatalf("默认格式渲染结果不符合预期:\n%s\n%s", line, expected)
+	}
+
+	formatter, err = parseLineFormat("%msg|%shortFile:%line|%pid|%goid|%hostname|100%%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !formatter.needGoid {
+		t.Fatal("日志格式使用了goroutine ID占位符，needGoid应为true")
+	}
+	line = string(formatter.format(nil, msg))
+	fmt.Println(line)
+	expected = "测试日志: 1|log_test.go:56|" + processID + "|7|" + hostname + "|100%"
+	if line != expected {
+		t.Fatalf("自定义格式渲染结果不符合预期:\n%s\n%s", line, expected)
+	}
+}
+
+func TestLocalLogLineFormat(t *testing.T) {
+	fmt.Println("----- TestLocalLogLineFormat -----")
+	logConfig := &Config{
+		LogLevelGlobal: LOG_LEVEL_DEBUG,
+		LogLineFormat:  "%level|%shortFile|%goid|%msg",
+	}
+	if err := InitLogger(logConfig); err != nil {
+		t.Fatal(err)
+	}
+	defer InitLogger(&Config{LogLineFormat: LOG_LINE_FORMAT_DEFAULT})
+	// 日志行格式不合法时返回错误，默认Logger保持原来的日志行格式
+	if err := InitLogger(&Config{LogLineFormat: "%level %mesage"}); err == nil {
+		t.Fatal("日志行格式不合法时InitLogger应该返回错误")
+	}
+	if defaultLogger.config.LogLineFormat != logConfig.LogLineFormat {
+		t.Fatalf("InitLogger返回错误时不应该修改日志行格式: %s", defaultLogger.config.LogLineFormat)
+	}
+	var buf bytes.Buffer
+	originalOutput := defaultLogger.stdLogger.Writer()
+	defaultLogger.stdLogger.SetOutput(&buf)
+	Infof("测试日志格式: %d", 1)
+	defaultLogger.stdLogger.SetOutput(originalOutput)
+	line := strings.TrimSpace(buf.String())
+	fmt.Println(line)
+	expected := "[ INFO]|log_format_test.go|" + strconv.FormatUint(getGoroutineID(), 10) + "|测试日志格式: 1"
+	if !strings.HasSuffix(line, expected) {
+		t.Fatalf("本地模式日志格式不符合预期: %s", line)
+	}
+}
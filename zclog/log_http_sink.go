@@ -0,0 +1,750 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_http_sink.go HTTP输出目标，将日志按条数、大小与时间间隔分批POST到日志接收服务，
+内置通用JSON、Loki push与Elasticsearch _bulk三种请求体格式
+*/
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTP输出目标的请求体格式定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_HTTP_FORMAT_JSON 通用JSON格式，请求体为日志JSON对象的数组，适用于一般的webhook
+	LOG_HTTP_FORMAT_JSON = iota + 1
+	// LOG_HTTP_FORMAT_LOKI Grafana Loki的push接口格式(/loki/api/v1/push)，日志级别作为level标签
+	LOG_HTTP_FORMAT_LOKI
+	// LOG_HTTP_FORMAT_ES_BULK Elasticsearch的_bulk接口格式(/_bulk)
+	LOG_HTTP_FORMAT_ES_BULK
+	// log_http_format_max HTTP输出目标的请求体格式定义值上限
+	log_http_format_max
+)
+
+// HTTP输出目标无法送达日志时的处理策略定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_HTTP_OVER_POLICY_DISCARD 发送队列已满或重试失败时丢弃该批日志
+	LOG_HTTP_OVER_POLICY_DISCARD = iota + 1
+	// LOG_HTTP_OVER_POLICY_SPOOL 重试失败或发送队列已满时写入本地缓存文件，恢复后重放
+	LOG_HTTP_OVER_POLICY_SPOOL
+	// log_http_over_policy_max HTTP输出目标无法送达日志时的处理策略定义值上限
+	log_http_over_policy_max
+)
+
+// 读取HTTP响应体的最大字节数，用于错误信息与Elasticsearch _bulk的结果检查
+const httpResponseBodyMaxSize = 1024 * 1024
+
+// HTTPSinkConfig HTTP输出目标配置
+type HTTPSinkConfig struct {
+	// 日志接收服务的URL，如"http://loki:3100/loki/api/v1/push"
+	URL string `json:"url" yaml:"url" mapstructure:"url"`
+	// 请求体格式，默认: LOG_HTTP_FORMAT_JSON
+	Format int `json:"format" yaml:"format" mapstructure:"format"`
+	// 额外的请求头，如认证信息
+	Headers map[string]string `json:"headers" yaml:"headers" mapstructure:"headers"`
+	// 是否对请求体做gzip压缩，默认: false
+	Gzip bool `json:"gzip" yaml:"gzip" mapstructure:"gzip"`
+	// 每批最多的日志条数，默认: 1000
+	BatchMaxCount int `json:"batch_max_count" yaml:"batch_max_count" mapstructure:"batch_max_count"`
+	// 每批日志编码后的大小上限，单位K，默认: 1024
+	BatchMaxSizeK int `json:"batch_max_size_k" yaml:"batch_max_size_k" mapstructure:"batch_max_size_k"`
+	// 未满一批的日志最长等待发送的时间间隔，单位毫秒，默认: 1000
+	BatchIntervalMilliSec int `json:"batch_interval_milli_sec" yaml:"batch_interval_milli_sec" mapstructure:"batch_interval_milli_sec"`
+	// 等待发送的批次队列长度，默认: 16
+	QueueSize int `json:"queue_size" yaml:"queue_size" mapstructure:"queue_size"`
+	// 请求超时时间，单位毫秒，默认: 10000
+	TimeoutMilliSec int `json:"timeout_milli_sec" yaml:"timeout_milli_sec" mapstructure:"timeout_milli_sec"`
+	// 响应5xx、429或请求失败时的重试次数，默认: 3，小于0时不重试
+	RetryMax int `json:"retry_max" yaml:"retry_max" mapstructure:"retry_max"`
+	// 最小重试间隔，单位毫秒，默认: 100，之后每次重试间隔加倍
+	RetryMinMilliSec int `json:"retry_min_milli_sec" yaml:"retry_min_milli_sec" mapstructure:"retry_min_milli_sec"`
+	// 最大重试间隔，单位毫秒，默认: 30000
+	RetryMaxMilliSec int `json:"retry_max_milli_sec" yaml:"retry_max_milli_sec" mapstructure:"retry_max_milli_sec"`
+	// 无法送达日志时的处理策略，默认: LOG_HTTP_OVER_POLICY_DISCARD
+	OverPolicy int `json:"over_policy" yaml:"over_policy" mapstructure:"over_policy"`
+	// 本地缓存文件路径，仅LOG_HTTP_OVER_POLICY_SPOOL使用，通过LogSinks配置时默认为 [LogFileDir]/[LogFileNamePrefix]_http.spool
+	SpoolFile string `json:"spool_file" yaml:"spool_file" mapstructure:"spool_file"`
+	// 本地缓存文件大小上限，单位M，默认: 100，超出后丢弃新的日志
+	SpoolMaxSizeM int `json:"spool_max_size_m" yaml:"spool_max_size_m" mapstructure:"spool_max_size_m"`
+	// Loki日志流的标签，仅LOG_HTTP_FORMAT_LOKI使用，level标签由日志级别决定
+	LokiLabels map[string]string `json:"loki_labels" yaml:"loki_labels" mapstructure:"loki_labels"`
+	// Elasticsearch索引名，仅LOG_HTTP_FORMAT_ES_BULK使用，默认: zcgolog
+	EsIndex string `json:"es_index" yaml:"es_index" mapstructure:"es_index"`
+	// 自定义HTTP客户端，配置后忽略TimeoutMilliSec
+	Client *http.Client `json:"-" yaml:"-" mapstructure:"-"`
+}
+
+// 检查HTTP输出目标配置
+func checkHTTPSinkConfig(httpConfig *HTTPSinkConfig) error {
+	if httpConfig == nil {
+		return fmt.Errorf("HTTP输出目标配置不可为空")
+	}
+	u, err := url.Parse(httpConfig.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("日志接收服务的URL不合法: %s", httpConfig.URL)
+	}
+	if httpConfig.Format != 0 && (httpConfig.Format < LOG_HTTP_FORMAT_JSON || httpConfig.Format >= log_http_format_max) {
+		return fmt.Errorf("HTTP输出目标的请求体格式不能超出有效范围")
+	}
+	if httpConfig.OverPolicy != 0 && (httpConfig.OverPolicy < LOG_HTTP_OVER_POLICY_DISCARD || httpConfig.OverPolicy >= log_http_over_policy_max) {
+		return fmt.Errorf("HTTP输出目标无法送达日志时的处理策略不能超出有效范围")
+	}
+	if httpConfig.BatchMaxCount < 0 || httpConfig.BatchMaxSizeK < 0 || httpConfig.BatchIntervalMilliSec < 0 || httpConfig.QueueSize < 0 ||
+		httpConfig.TimeoutMilliSec < 0 || httpConfig.RetryMinMilliSec < 0 || httpConfig.RetryMaxMilliSec < 0 || httpConfig.SpoolMaxSizeM < 0 {
+		return fmt.Errorf("HTTP输出目标的批次、队列、超时、重试间隔与本地缓存配置不能小于0")
+	}
+	return nil
+}
+
+// HTTP输出目标的默认本地缓存文件路径
+func defaultHTTPSpoolFile(logConfig *Config) string {
+	return defaultSpoolFile(logConfig, "http")
+}
+
+// 一批等待发送的日志
+//
+//	每条日志编码为一行"[纳秒时间戳] [日志级别] [JSON对象]"，与本地缓存文件的格式相同，发送时再转换为请求体格式。
+type httpBatch struct {
+	data  []byte
+	count int
+	// Sync的同步点，非nil时不包含日志，处理到该批次时写入本地缓存文件的落盘结果
+	synced chan error
+}
+
+// 将日志编码为一行追加到buf
+func appendHTTPRecord(buf []byte, msg *logMsg) []byte {
+	buf = strconv.AppendInt(buf, msg.pushTime.UnixNano(), 10)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(msg.logLevel), 10)
+	buf = append(buf, ' ')
+	buf = jsonEncoder{}.encode(buf, msg)
+	return append(buf, '\n')
+}
+
+// 遍历批次中的每行日志
+func rangeHTTPRecords(data []byte, fn func(ts []byte, logLevel int, doc []byte)) {
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		ts, rest, _ := bytes.Cut(line, []byte{' '})
+		level, doc, _ := bytes.Cut(rest, []byte{' '})
+		logLevel, _ := strconv.Atoi(string(level))
+		fn(ts, logLevel, doc)
+	}
+}
+
+// HTTP输出目标
+//
+//	Write将日志追加到当前批次，达到条数或大小上限、到达时间间隔、Sync或Close时将当前批次放入发送队列，
+//	由后台goroutine按顺序发送，Write不会等待HTTP请求。响应5xx、429或请求失败时按指数退避间隔重试，
+//	重试失败或发送队列已满时按OverPolicy丢弃日志或写入本地缓存文件。
+//	写入本地缓存后，之后的批次同样写入本地缓存，按退避间隔尝试重放，全部重放后恢复直接发送。
+//	lock只在追加日志与放入发送队列时持有，不会在持有lock时阻塞等待发送队列或HTTP请求。
+type httpSink struct {
+	// 保护当前批次与关闭状态
+	lock   sync.Mutex
+	config HTTPSinkConfig
+	client *http.Client
+	batch  *httpBatch
+	// 单批日志的大小上限
+	batchMaxSize int
+	queue        chan *httpBatch
+	// 发送goroutine从发送队列取出批次时通知，用于Sync与Close等待发送队列的空位
+	dequeued chan struct{}
+	closed   bool
+	// Close时关闭，用于停止定时发送与中断重试等待
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	// 本地缓存文件，OverPolicy为LOG_HTTP_OVER_POLICY_SPOOL时非nil
+	spool *logSpool
+	// 保护本地缓存文件，发送队列已满时Write与发送goroutine都会写入本地缓存
+	spoolLock sync.Mutex
+
+	// 以下字段只由发送goroutine访问
+	// 本地缓存重放的指数退避控制
+	backoff reconnectBackoff
+	// 请求体缓冲区，payload为压缩前的请求体
+	payload []byte
+	body    bytes.Buffer
+	gz      *gzip.Writer
+	// 是否处于发送失败状态，用于只在开始失败时输出一次错误信息
+	failing bool
+
+	// 丢弃的日志数量
+	dropped atomic.Int64
+}
+
+// NewHTTPSink 创建HTTP输出目标
+//
+//	OverPolicy为LOG_HTTP_OVER_POLICY_SPOOL时必须配置SpoolFile，本地缓存文件采用默认的日志文件权限。
+func NewHTTPSink(httpConfig *HTTPSinkConfig) (Sink, error) {
+	return newHTTPSink(httpConfig, newDefaultConfig())
+}
+
+// 创建HTTP输出目标并启动发送goroutine，logConfig用于本地缓存文件的权限与属组
+func newHTTPSink(httpConfig *HTTPSinkConfig, logConfig *Config) (*httpSink, error) {
+	if err := checkHTTPSinkConfig(httpConfig); err != nil {
+		return nil, err
+	}
+	s := &httpSink{config: *httpConfig, closing: make(chan struct{})}
+	if s.config.Format == 0 {
+		s.config.Format = LOG_HTTP_FORMAT_JSON
+	}
+	if s.config.BatchMaxCount == 0 {
+		s.config.BatchMaxCount = 1000
+	}
+	if s.config.BatchMaxSizeK == 0 {
+		s.config.BatchMaxSizeK = 1024
+	}
+	if s.config.BatchIntervalMilliSec == 0 {
+		s.config.BatchIntervalMilliSec = 1000
+	}
+	if s.config.QueueSize == 0 {
+		s.config.QueueSize = 16
+	}
+	if s.config.TimeoutMilliSec == 0 {
+		s.config.TimeoutMilliSec = 10000
+	}
+	if s.config.RetryMax == 0 {
+		s.config.RetryMax = 3
+	}
+	if s.config.OverPolicy == 0 {
+		s.config.OverPolicy = LOG_HTTP_OVER_POLICY_DISCARD
+	}
+	if s.config.SpoolMaxSizeM == 0 {
+		s.config.SpoolMaxSizeM = 100
+	}
+	if s.config.EsIndex == "" {
+		s.config.EsIndex = "zcgolog"
+	}
+	s.client = s.config.Client
+	if s.client == nil {
+		s.client = &http.Client{Timeout: time.Duration(s.config.TimeoutMilliSec) * time.Millisecond}
+	}
+	s.batchMaxSize = s.config.BatchMaxSizeK * 1024
+	s.backoff = newReconnectBackoff(s.config.RetryMinMilliSec, s.config.RetryMaxMilliSec)
+	if s.config.OverPolicy == LOG_HTTP_OVER_POLICY_SPOOL {
+		if s.config.SpoolFile == "" {
+			return nil, fmt.Errorf("HTTP输出目标的处理策略为LOG_HTTP_OVER_POLICY_SPOOL时本地缓存文件不可为空")
+		}
+		spool, err := openLogSpool(s.config.SpoolFile, s.config.SpoolMaxSizeM, logConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.spool = spool
+	}
+	s.batch = &httpBatch{}
+	s.queue = make(chan *httpBatch, s.config.QueueSize)
+	s.dequeued = make(chan struct{}, 1)
+	s.wg.Add(2)
+	go s.runSender()
+	go s.runTicker()
+	return s, nil
+}
+
+func (s *httpSink) Write(entry *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return fmt.Errorf("HTTP输出目标已关闭")
+	}
+	s.batch.data = appendHTTPRecord(s.batch.data, entry.encodeMsg())
+	s.batch.count++
+	if s.batch.count >= s.config.BatchMaxCount || len(s.batch.data) >= s.batchMaxSize {
+		return s.enqueueBatch(false)
+	}
+	return nil
+}
+
+// 将当前批次放入发送队列，需要在持有lock时调用
+//
+//	发送队列已满时，wait为false则不等待，按处理策略将当前批次写入本地缓存或丢弃；
+//	wait为true则释放lock等待发送队列的空位，等待期间其他goroutine追加的日志仍进入当前批次，不影响日志顺序，
+//	等待期间输出目标被关闭时不再等待，当前批次由Close处理。
+func (s *httpSink) enqueueBatch(wait bool) error {
+	closed := s.closed
+	for s.batch.count > 0 {
+		select {
+		case s.queue <- s.batch:
+			s.batch = &httpBatch{}
+			return nil
+		default:
+		}
+		if !wait {
+			batch := s.batch
+			s.batch = &httpBatch{}
+			return s.overflowBatch(batch)
+		}
+		s.waitQueue()
+		if s.closed != closed {
+			return nil
+		}
+	}
+	return nil
+}
+
+// 发送队列已满时处理批次，处理策略为LOG_HTTP_OVER_POLICY_SPOOL时写入本地缓存，否则丢弃
+//
+//	写入本地缓存的批次由发送goroutine按退避间隔重放，可能先于发送队列中较早的批次送达。
+func (s *httpSink) overflowBatch(batch *httpBatch) error {
+	if s.spool != nil {
+		s.spoolBatch(batch.data, batch.count)
+		return nil
+	}
+	if s.dropped.Add(int64(batch.count)) == int64(batch.count) {
+		return fmt.Errorf("HTTP输出目标的发送队列已满，丢弃日志 %d 条", batch.count)
+	}
+	return nil
+}
+
+// 释放lock等待发送goroutine从发送队列取出批次，需要在持有lock时调用，返回时重新持有lock
+func (s *httpSink) waitQueue() {
+	s.lock.Unlock()
+	select {
+	case <-s.dequeued:
+	case <-time.After(time.Duration(s.config.BatchIntervalMilliSec) * time.Millisecond):
+	}
+	s.lock.Lock()
+}
+
+// 按时间间隔将未满的批次放入发送队列
+func (s *httpSink) runTicker() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.config.BatchIntervalMilliSec) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			s.lock.Lock()
+			if !s.closed {
+				if err := s.enqueueBatch(false); err != nil {
+					fmt.Printf("zclog HTTP输出目标发生错误: %s\n", err)
+				}
+			}
+			s.lock.Unlock()
+		}
+	}
+}
+
+// 按顺序发送队列中的批次，发送队列关闭后退出
+func (s *httpSink) runSender() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.config.BatchIntervalMilliSec) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch, ok := <-s.queue:
+			if !ok {
+				return
+			}
+			select {
+			case s.dequeued <- struct{}{}:
+			default:
+			}
+			s.handleBatch(batch)
+		case <-ticker.C:
+			// 没有新的日志时也按退避间隔尝试重放本地缓存
+			if s.spoolPending() && s.backoff.ready() {
+				s.replaySpool()
+			}
+		}
+	}
+}
+
+// 处理一个批次
+func (s *httpSink) handleBatch(batch *httpBatch) {
+	if batch.synced != nil {
+		var err error
+		if s.spool != nil {
+			s.spoolLock.Lock()
+			err = s.spool.sync()
+			s.spoolLock.Unlock()
+		}
+		batch.synced <- err
+		return
+	}
+	if s.spoolPending() {
+		if s.backoff.ready() {
+			s.replaySpool()
+		}
+		if s.spoolPending() {
+			s.spoolBatch(batch.data, batch.count)
+			return
+		}
+	}
+	if err := s.sendWithRetry(batch.data); err != nil {
+		s.undeliverable(batch.data, batch.count, err)
+		return
+	}
+	s.recovered()
+}
+
+// 发送失败，按处理策略写入本地缓存或丢弃
+//
+//	日志接收服务拒绝的日志(不可重试的错误响应，如4xx或Elasticsearch _bulk部分写入失败)重放也不会成功，
+//	且可能重复写入已写入的日志，因此直接丢弃，不写入本地缓存也不推迟之后的发送。
+func (s *httpSink) undeliverable(data []byte, count int, err error) {
+	if retryable, isHTTPErr := httpErrorRetryable(err); isHTTPErr && !retryable {
+		s.dropped.Add(int64(count))
+		fmt.Printf("zclog HTTP输出目标丢弃被日志接收服务拒绝的日志 %d 条: %s\n", count, err)
+		return
+	}
+	if !s.failing {
+		s.failing = true
+		fmt.Printf("zclog HTTP输出目标发送日志失败，恢复前不再重复提示: %s\n", err)
+	}
+	if s.spool != nil {
+		s.backoff.fail()
+		s.spoolBatch(data, count)
+		return
+	}
+	s.dropped.Add(int64(count))
+}
+
+// 发送成功，从失败状态恢复时输出丢弃的日志数量
+func (s *httpSink) recovered() {
+	if !s.failing {
+		return
+	}
+	s.failing = false
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		fmt.Printf("zclog HTTP输出目标已恢复，期间丢弃日志 %d 条\n", dropped)
+	} else {
+		fmt.Println("zclog HTTP输出目标已恢复")
+	}
+}
+
+// 本地缓存中是否有待重放的日志
+func (s *httpSink) spoolPending() bool {
+	if s.spool == nil {
+		return false
+	}
+	s.spoolLock.Lock()
+	defer s.spoolLock.Unlock()
+	return s.spool.pending()
+}
+
+// 将批次写入本地缓存，缓存文件已满时丢弃
+func (s *httpSink) spoolBatch(data []byte, count int) {
+	s.spoolLock.Lock()
+	ok, err := s.spool.append(data)
+	s.spoolLock.Unlock()
+	if err != nil {
+		fmt.Printf("zclog HTTP输出目标发生错误: %s\n", err)
+	}
+	if !ok {
+		s.dropped.Add(int64(count))
+	}
+}
+
+// 按顺序重放本地缓存中的日志，每批只发送一次，失败时推迟下次重放
+//
+//	只在读取与推进本地缓存时持有spoolLock，发送期间Write仍可向本地缓存追加日志。
+func (s *httpSink) replaySpool() {
+	var data []byte
+	for s.spoolPending() {
+		var err error
+		var count int
+		s.spoolLock.Lock()
+		data, count, err = s.spool.read(data[:0], s.batchMaxSize, s.config.BatchMaxCount)
+		s.spoolLock.Unlock()
+		if err == nil && count > 0 {
+			err = s.send(data)
+			if retryable, isHTTPErr := httpErrorRetryable(err); err != nil && isHTTPErr && !retryable {
+				// 日志接收服务拒绝的日志重放也不会成功，直接丢弃
+				fmt.Printf("zclog HTTP输出目标丢弃本地缓存中被拒绝的日志 %d 条: %s\n", count, err)
+				err = nil
+			}
+		}
+		if err != nil {
+			s.backoff.fail()
+			return
+		}
+		s.spoolLock.Lock()
+		err = s.spool.advance(len(data))
+		s.spoolLock.Unlock()
+		if err != nil {
+			fmt.Printf("zclog HTTP输出目标发生错误: %s\n", err)
+			s.backoff.fail()
+			return
+		}
+	}
+	s.backoff.reset()
+	s.recovered()
+}
+
+// 发送一批日志，可重试的错误按指数退避间隔重试，Close后不再等待重试
+func (s *httpSink) sendWithRetry(data []byte) error {
+	delay := s.backoff.min
+	for attempt := 0; ; attempt++ {
+		err := s.send(data)
+		if err == nil {
+			return nil
+		}
+		if retryable, _ := httpErrorRetryable(err); !retryable || attempt >= s.config.RetryMax {
+			return err
+		}
+		select {
+		case <-s.closing:
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > s.backoff.max {
+			delay = s.backoff.max
+		}
+	}
+}
+
+// 日志接收服务返回的错误响应
+type httpStatusError struct {
+	status int
+	body   string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("日志接收服务返回错误响应: %d %s", e.status, e.body)
+}
+
+// 判断错误是否可以重试，返回值isHTTPErr表示是否是日志接收服务返回的错误响应
+//
+//	响应5xx与429可以重试，其他错误响应不重试；请求失败(如无法连接、超时)可以重试。
+func httpErrorRetryable(err error) (retryable bool, isHTTPErr bool) {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status >= 500 || statusErr.status == http.StatusTooManyRequests, true
+	}
+	return err != nil, false
+}
+
+// 将一批日志转换为请求体格式并发送
+func (s *httpSink) send(data []byte) error {
+	s.body.Reset()
+	var w io.Writer = &s.body
+	if s.config.Gzip {
+		if s.gz == nil {
+			s.gz = gzip.NewWriter(&s.body)
+		} else {
+			s.gz.Reset(&s.body)
+		}
+		w = s.gz
+	}
+	var contentType string
+	switch s.config.Format {
+	case LOG_HTTP_FORMAT_LOKI:
+		contentType = "application/json"
+		s.payload = s.appendLokiPayload(s.payload[:0], data)
+	case LOG_HTTP_FORMAT_ES_BULK:
+		contentType = "application/x-ndjson"
+		s.payload = s.appendEsBulkPayload(s.payload[:0], data)
+	default:
+		contentType = "application/json"
+		s.payload = appendJSONArrayPayload(s.payload[:0], data)
+	}
+	if _, err := w.Write(s.payload); err != nil {
+		return err
+	}
+	if s.config.Gzip {
+		if err := s.gz.Close(); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(s.body.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range s.config.Headers {
+		// Host请求头由req.Host决定，设置在Header中不会生效
+		if http.CanonicalHeaderKey(k) == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, httpResponseBodyMaxSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(respBody) > 512 {
+			respBody = respBody[:512]
+		}
+		// 错误响应的状态码与响应体
+		return &httpStatusError{status: resp.StatusCode, body: string(bytes.TrimSpace(respBody))}
+	}
+	if s.config.Format == LOG_HTTP_FORMAT_ES_BULK {
+		// _bulk接口部分日志写入失败时仍然返回200，需要检查响应中的errors
+		var bulkResp struct {
+			Errors bool `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &bulkResp) == nil && bulkResp.Errors {
+			// 重试会导致已写入的日志重复，按不可重试的错误响应处理
+			return &httpStatusError{status: resp.StatusCode, body: "_bulk接口返回部分日志写入失败"}
+		}
+	}
+	return nil
+}
+
+// 通用JSON格式: [{...},{...}]
+func appendJSONArrayPayload(buf []byte, data []byte) []byte {
+	buf = append(buf, '[')
+	first := true
+	rangeHTTPRecords(data, func(_ []byte, _ int, doc []byte) {
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = append(buf, doc...)
+	})
+	return append(buf, ']')
+}
+
+// Loki push格式: {"streams":[{"stream":{标签},"values":[["纳秒时间戳","日志行"],...]},...]}
+//
+//	每个日志级别一个日志流，日志行为JSON对象。
+func (s *httpSink) appendLokiPayload(buf []byte, data []byte) []byte {
+	streams := map[int][][2][]byte{}
+	var levels []int
+	rangeHTTPRecords(data, func(ts []byte, logLevel int, doc []byte) {
+		if _, ok := streams[logLevel]; !ok {
+			levels = append(levels, logLevel)
+		}
+		streams[logLevel] = append(streams[logLevel], [2][]byte{ts, doc})
+	})
+	labelNames := make([]string, 0, len(s.config.LokiLabels))
+	for name := range s.config.LokiLabels {
+		if name != "level" {
+			labelNames = append(labelNames, name)
+		}
+	}
+	sort.Strings(labelNames)
+	buf = append(buf, `{"streams":[`...)
+	for i, logLevel := range levels {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"stream":{`...)
+		for _, name := range labelNames {
+			buf = appendJSONString(buf, name)
+			buf = append(buf, ':')
+			buf = appendJSONString(buf, s.config.LokiLabels[name])
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `"level":`...)
+		buf = appendJSONString(buf, GetLogLevelStrByInt(logLevel))
+		buf = append(buf, `},"values":[`...)
+		for j, value := range streams[logLevel] {
+			if j > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, `["`...)
+			buf = append(buf, value[0]...)
+			buf = append(buf, `",`...)
+			buf = appendJSONString(buf, string(value[1]))
+			buf = append(buf, ']')
+		}
+		buf = append(buf, "]}"...)
+	}
+	return append(buf, "]}"...)
+}
+
+// Elasticsearch _bulk格式: 每条日志一行index操作与一行JSON对象
+func (s *httpSink) appendEsBulkPayload(buf []byte, data []byte) []byte {
+	rangeHTTPRecords(data, func(_ []byte, _ int, doc []byte) {
+		buf = append(buf, `{"index":{"_index":`...)
+		buf = appendJSONString(buf, s.config.EsIndex)
+		buf = append(buf, "}}\n"...)
+		buf = append(buf, doc...)
+		buf = append(buf, '\n')
+	})
+	return buf
+}
+
+// Sync 将当前批次与发送队列中的日志发送完毕(或按处理策略写入本地缓存、丢弃)，并将本地缓存文件落盘
+func (s *httpSink) Sync() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	_ = s.enqueueBatch(true)
+	synced := make(chan error, 1)
+	barrier := &httpBatch{synced: synced}
+	for !s.closed {
+		select {
+		case s.queue <- barrier:
+			s.lock.Unlock()
+			return <-synced
+		default:
+			s.waitQueue()
+		}
+	}
+	// 等待期间输出目标被关闭，剩余的日志由Close处理
+	s.lock.Unlock()
+	return nil
+}
+
+// Close 发送剩余的日志后停止发送goroutine，关闭本地缓存文件
+//
+//	Close之后不再等待重试，发送失败的日志按处理策略写入本地缓存或丢弃。
+func (s *httpSink) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.closing)
+	_ = s.enqueueBatch(true)
+	close(s.queue)
+	s.lock.Unlock()
+	s.wg.Wait()
+	if dropped := s.dropped.Load(); dropped > 0 {
+		fmt.Printf("zclog HTTP输出目标关闭，未能送达而丢弃的日志 %d 条\n", dropped)
+	}
+	if s.spool != nil {
+		return s.spool.close()
+	}
+	return nil
+}
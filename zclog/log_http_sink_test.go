@@ -0,0 +1,427 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试用的日志接收服务收到的请求
+type testHTTPRequest struct {
+	host   string
+	header http.Header
+	body   []byte
+	status int
+}
+
+// 启动测试用的日志接收服务，status返回每次请求的响应状态码
+func startTestHTTPServer(t *testing.T, status func() int) (*httptest.Server, func() []testHTTPRequest) {
+	var lock sync.Mutex
+	var requests []testHTTPRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("请求体不是gzip格式: %s", err)
+				return
+			}
+			reader = gz
+		}
+		body, _ := io.ReadAll(reader)
+		code := status()
+		lock.Lock()
+		requests = append(requests, testHTTPRequest{host: r.Host, header: r.Header, body: body, status: code})
+		lock.Unlock()
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []testHTTPRequest {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]testHTTPRequest(nil), requests...)
+	}
+}
+
+// 解析通用JSON格式请求体中的日志消息
+func jsonArrayMsgs(t *testing.T, body []byte) []string {
+	var docs []map[string]any
+	if err := json.Unmarshal(body, &docs); err != nil {
+		t.Fatalf("请求体不是JSON数组: %s", body)
+	}
+	msgs := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		msgs = append(msgs, fmt.Sprint(doc["msg"]))
+	}
+	return msgs
+}
+
+func TestHTTPSinkBatch(t *testing.T) {
+	fmt.Println("----- TestHTTPSinkBatch -----")
+	server, requests := startTestHTTPServer(t, func() int { return http.StatusOK })
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      t.TempDir(),
+		LogMod:          LOG_MODE_SERVER,
+		LogSinks: []SinkConfig{{
+			Type:  LOG_SINK_HTTP,
+			Level: LOG_LEVEL_WARNING,
+			HTTP: &HTTPSinkConfig{
+				URL:                   server.URL,
+				Gzip:                  true,
+				Headers:               map[string]string{"Authorization": "Bearer zcgolog", "Host": "logs.example.com"},
+				BatchMaxCount:         10,
+				BatchIntervalMilliSec: 60000,
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 25; i++ {
+		logger.Warn(strconv.Itoa(i))
+	}
+	// Sync发送未满一批的日志，并等待发送完毕
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	reqs := requests()
+	if len(reqs) != 3 {
+		t.Fatalf("请求次数不符合预期: %d", len(reqs))
+	}
+	var msgs []string
+	for i, req := range reqs {
+		if req.header.Get("Authorization") != "Bearer zcgolog" || req.header.Get("Content-Type") != "application/json" || req.host != "logs.example.com" {
+			t.Fatalf("请求头不符合预期: %s %v", req.host, req.header)
+		}
+		batchMsgs := jsonArrayMsgs(t, req.body)
+		if (i < 2 && len(batchMsgs) != 10) || (i == 2 && len(batchMsgs) != 5) {
+			t.Fatalf("第%d批日志条数不符合预期: %d", i+1, len(batchMsgs))
+		}
+		msgs = append(msgs, batchMsgs...)
+	}
+	for i, msg := range msgs {
+		if msg != strconv.Itoa(i) {
+			t.Fatalf("第%d条日志不符合预期: %s", i+1, msg)
+		}
+	}
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未满一批的日志到达时间间隔后发送
+	sink, err := NewHTTPSink(&HTTPSinkConfig{URL: server.URL, BatchIntervalMilliSec: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "定时发送的日志")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && len(requests()) == 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if reqs = requests(); len(reqs) != 4 || jsonArrayMsgs(t, reqs[3].body)[0] != "定时发送的日志" {
+		t.Fatal("未满一批的日志应该在到达时间间隔后发送")
+	}
+
+	// HTTP输出目标配置校验
+	invalidConfigs := []*HTTPSinkConfig{
+		nil,
+		{URL: "localhost:3100"},
+		{URL: server.URL, Format: log_http_format_max},
+		{URL: server.URL, OverPolicy: log_http_over_policy_max},
+		{URL: server.URL, BatchMaxCount: -1},
+		{URL: server.URL, OverPolicy: LOG_HTTP_OVER_POLICY_SPOOL},
+	}
+	for i, httpConfig := range invalidConfigs {
+		config := newDefaultConfig()
+		config.LogSinks = []SinkConfig{{Type: LOG_SINK_HTTP, HTTP: httpConfig}}
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("第%d个HTTP输出配置应该校验失败", i+1)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	if _, err := NewHTTPSink(&HTTPSinkConfig{URL: server.URL, OverPolicy: LOG_HTTP_OVER_POLICY_SPOOL}); err == nil {
+		t.Fatal("处理策略为LOG_HTTP_OVER_POLICY_SPOOL且未配置本地缓存文件时应该创建失败")
+	}
+}
+
+func TestHTTPSinkFormats(t *testing.T) {
+	fmt.Println("----- TestHTTPSinkFormats -----")
+	server, requests := startTestHTTPServer(t, func() int { return http.StatusOK })
+	writeAndSync := func(httpConfig *HTTPSinkConfig) testHTTPRequest {
+		sink, err := NewHTTPSink(httpConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = sink.Close()
+		}()
+		_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "第一条"))
+		_ = sink.Write(newTestEntry(LOG_LEVEL_ERROR, "第二条"))
+		_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "第三条"))
+		if err := sink.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		reqs := requests()
+		return reqs[len(reqs)-1]
+	}
+
+	// Loki push格式，每个日志级别一个日志流
+	req := writeAndSync(&HTTPSinkConfig{URL: server.URL, Format: LOG_HTTP_FORMAT_LOKI, LokiLabels: map[string]string{"app": "myapp"}})
+	var lokiPush struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(req.body, &lokiPush); err != nil {
+		t.Fatalf("Loki请求体不符合预期: %s", req.body)
+	}
+	if len(lokiPush.Streams) != 2 || lokiPush.Streams[0].Stream["app"] != "myapp" || lokiPush.Streams[0].Stream["level"] != "info" ||
+		lokiPush.Streams[1].Stream["level"] != "error" || len(lokiPush.Streams[0].Values) != 2 || len(lokiPush.Streams[1].Values) != 1 {
+		t.Fatalf("Loki请求体不符合预期: %s", req.body)
+	}
+	if ts, err := strconv.ParseInt(lokiPush.Streams[0].Values[0][0], 10, 64); err != nil || time.Since(time.Unix(0, ts)) > time.Minute {
+		t.Fatalf("Loki日志的纳秒时间戳不符合预期: %s", lokiPush.Streams[0].Values[0][0])
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(lokiPush.Streams[0].Values[1][1]), &doc); err != nil || doc["msg"] != "第三条" {
+		t.Fatalf("Loki日志行不符合预期: %s", lokiPush.Streams[0].Values[1][1])
+	}
+
+	// Elasticsearch _bulk格式
+	req = writeAndSync(&HTTPSinkConfig{URL: server.URL, Format: LOG_HTTP_FORMAT_ES_BULK, EsIndex: "app-logs"})
+	lines := strings.Split(strings.TrimSuffix(string(req.body), "\n"), "\n")
+	if req.header.Get("Content-Type") != "application/x-ndjson" || len(lines) != 6 || lines[0] != `{"index":{"_index":"app-logs"}}` {
+		t.Fatalf("Elasticsearch请求体不符合预期: %s", req.body)
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &doc); err != nil || doc["msg"] != "第二条" || doc["level"] != "error" {
+		t.Fatalf("Elasticsearch日志不符合预期: %s", lines[3])
+	}
+}
+
+func TestHTTPSinkRetry(t *testing.T) {
+	fmt.Println("----- TestHTTPSinkRetry -----")
+	var status atomic.Int64
+	var calls atomic.Int64
+	server, requests := startTestHTTPServer(t, func() int {
+		// 前两次请求返回503，之后返回status
+		if calls.Add(1) <= 2 {
+			return http.StatusServiceUnavailable
+		}
+		return int(status.Load())
+	})
+	status.Store(http.StatusOK)
+	sink, err := newHTTPSink(&HTTPSinkConfig{URL: server.URL, RetryMinMilliSec: 10}, newDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "重试后发送成功的日志"))
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if reqs := requests(); len(reqs) != 3 || jsonArrayMsgs(t, reqs[2].body)[0] != "重试后发送成功的日志" || sink.dropped.Load() != 0 {
+		t.Fatalf("响应5xx时应该重试: 请求次数 %d, 丢弃 %d", len(reqs), sink.dropped.Load())
+	}
+	// 响应4xx时不重试，按处理策略丢弃
+	status.Store(http.StatusBadRequest)
+	_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "被拒绝的日志"))
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if reqs := requests(); len(reqs) != 4 || sink.dropped.Load() != 1 {
+		t.Fatalf("响应4xx时不应该重试: 请求次数 %d, 丢弃 %d", len(reqs), sink.dropped.Load())
+	}
+}
+
+func TestHTTPSinkSpool(t *testing.T) {
+	fmt.Println("----- TestHTTPSinkSpool -----")
+	var status atomic.Int64
+	status.Store(http.StatusInternalServerError)
+	server, requests := startTestHTTPServer(t, func() int { return int(status.Load()) })
+	logDir := t.TempDir()
+	config := newDefaultConfig()
+	config.LogFileDir = logDir
+	httpConfig := &HTTPSinkConfig{
+		URL:                   server.URL,
+		BatchMaxCount:         10,
+		BatchIntervalMilliSec: 20,
+		RetryMax:              -1,
+		RetryMinMilliSec:      50,
+		RetryMaxMilliSec:      50,
+		OverPolicy:            LOG_HTTP_OVER_POLICY_SPOOL,
+		SpoolFile:             defaultHTTPSpoolFile(config),
+	}
+	sink, err := newHTTPSink(httpConfig, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 日志接收服务不可用时写入本地缓存，之后的日志同样写入本地缓存以保证顺序
+	for i := 0; i < 35; i++ {
+		_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, strconv.Itoa(i)))
+	}
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests()) == 0 {
+		t.Fatal("日志接收服务不可用时应该尝试发送")
+	}
+	fileStat, err := os.Stat(httpConfig.SpoolFile)
+	if err != nil || fileStat.Size() == 0 {
+		t.Fatalf("日志接收服务不可用时日志应该写入本地缓存: %v", err)
+	}
+
+	// 日志接收服务恢复后按顺序重放本地缓存
+	status.Store(http.StatusOK)
+	time.Sleep(100 * time.Millisecond)
+	_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "35"))
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for _, req := range requests() {
+		if req.status == http.StatusOK {
+			msgs = append(msgs, jsonArrayMsgs(t, req.body)...)
+		}
+	}
+	if len(msgs) != 36 {
+		t.Fatalf("重放的日志数量不符合预期: %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg != strconv.Itoa(i) {
+			t.Fatalf("第%d条日志不符合预期: %s", i+1, msg)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(logDir, "zcgolog_http.spool")); !os.IsNotExist(err) {
+		t.Fatalf("本地缓存为空时关闭后应该删除缓存文件: %v", err)
+	}
+	if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "关闭后的日志")); err == nil {
+		t.Fatal("关闭后写入应该返回错误")
+	}
+}
+
+func TestHTTPSinkSpoolQueueFull(t *testing.T) {
+	fmt.Println("----- TestHTTPSinkSpoolQueueFull -----")
+	// 日志接收服务阻塞期间发送队列会被占满
+	release := make(chan struct{})
+	var released atomic.Bool
+	server, requests := startTestHTTPServer(t, func() int {
+		if !released.Load() {
+			<-release
+		}
+		return http.StatusOK
+	})
+	config := newDefaultConfig()
+	config.LogFileDir = t.TempDir()
+	httpConfig := &HTTPSinkConfig{
+		URL:                   server.URL,
+		BatchMaxCount:         1,
+		BatchIntervalMilliSec: 20,
+		QueueSize:             1,
+		RetryMinMilliSec:      20,
+		RetryMaxMilliSec:      20,
+		OverPolicy:            LOG_HTTP_OVER_POLICY_SPOOL,
+		SpoolFile:             defaultHTTPSpoolFile(config),
+	}
+	sink, err := newHTTPSink(httpConfig, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 发送队列已满时写入本地缓存，Write不阻塞
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, strconv.Itoa(i)))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("发送队列已满时Write不应该阻塞")
+	}
+	if !sink.spoolPending() {
+		t.Fatal("发送队列已满时日志应该写入本地缓存")
+	}
+	// 日志接收服务恢复后重放本地缓存，所有日志都能送达
+	released.Store(true)
+	close(release)
+	deadline := time.Now().Add(3 * time.Second)
+	for sink.spoolPending() && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	received := map[string]bool{}
+	for _, req := range requests() {
+		for _, msg := range jsonArrayMsgs(t, req.body) {
+			received[msg] = true
+		}
+	}
+	if len(received) != 20 || sink.dropped.Load() != 0 {
+		t.Fatalf("送达的日志数量不符合预期: %d, 丢弃 %d", len(received), sink.dropped.Load())
+	}
+}
+
+func TestHTTPSinkSpoolRejected(t *testing.T) {
+	fmt.Println("----- TestHTTPSinkSpoolRejected -----")
+	server, requests := startTestHTTPServer(t, func() int { return http.StatusBadRequest })
+	config := newDefaultConfig()
+	config.LogFileDir = t.TempDir()
+	sink, err := newHTTPSink(&HTTPSinkConfig{
+		URL:                   server.URL,
+		BatchIntervalMilliSec: 20,
+		RetryMinMilliSec:      10,
+		OverPolicy:            LOG_HTTP_OVER_POLICY_SPOOL,
+		SpoolFile:             defaultHTTPSpoolFile(config),
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	// 被拒绝的日志直接丢弃，不写入本地缓存，也不会重放
+	_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "被拒绝的日志"))
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if reqs := requests(); len(reqs) != 1 || sink.dropped.Load() != 1 || sink.spoolPending() || sink.failing {
+		t.Fatalf("响应4xx时不应该写入本地缓存: 请求次数 %d, 丢弃 %d", len(reqs), sink.dropped.Load())
+	}
+}
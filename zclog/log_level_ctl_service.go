@@ -15,6 +15,8 @@ zclog/log_level_ctl_service.go 日志级别控制服务，提供httpAPI来在线
 */
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -22,14 +24,44 @@ import (
 	"sync"
 )
 
-// 日志级别控制
-var logLevelCtl = map[string]int{}
+// 日志级别控制监听服务，未启动或已停止时为nil
+var logCtlServer *http.Server
+
+// 启动与停止日志级别控制监听服务时使用的锁
+var logCtlServerLock sync.Mutex
+
+// Level 默认Logger的全局日志级别
+//
+// Deprecated: 直接读写该变量不是并发安全的，读取请改用GetLevel，修改请改用SetLevel，该变量将在下一个版本移除。
+// 为兼容旧代码，默认Logger的全局日志级别变化时同步更新该变量；直接给该变量赋值不再影响日志输出。
 var Level = LOG_LEVEL_INFO
-var runLogCtlServeOnce sync.Once
+
+// 同步更新Level时使用的锁
+var deprecatedLevelLock sync.Mutex
+
+// 默认Logger的全局日志级别变化时同步更新已废弃的Level
+func syncDeprecatedLevel(level int) {
+	deprecatedLevelLock.Lock()
+	defer deprecatedLevelLock.Unlock()
+	Level = level
+}
+
+// GetLevel 获取默认Logger的全局日志级别
+//  替代已废弃的包级变量`Level`，修改全局日志级别请使用`SetLevel`。
+func GetLevel() int {
+	return defaultLogger.GetLevel()
+}
+
+// SetLevel 修改默认Logger的全局日志级别，效果与通过日志级别控制服务修改全局日志级别相同
+func SetLevel(level int) {
+	defaultLogger.SetLevel(level)
+}
 
 // 处理指定函数的日志级别调整请求
 //  URL参数为logger和level;
 //  logger是调整目标，对应具体函数的完整包名路径，如: gitee.com/zhaochuninhefei/zcgolog/log.writeLog
+//  logger也可以是类型(如 gitee.com/zhaochuninhefei/zcgolog/zclog.(*Logger))、包(如 gitee.com/zhaochuninhefei/zcgolog/zclog)
+//  或包子树(如 gitee.com/zhaochuninhefei/zcgolog/...)，多条规则都匹配时最长的规则生效
 //  level是调整后的日志级别，支持从1到6，分别是 DEBUG,INFO,WARNNING,ERROR,PANIC,FATAL
 //  一个完整的请求URL示例:http://localhost:9300/zcgolog/api/level/ctl?logger=gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog&level=1
 func handleLogLevelCtl(w http.ResponseWriter, req *http.Request) {
@@ -45,14 +77,14 @@ func handleLogLevelCtl(w http.ResponseWriter, req *http.Request) {
 		}
 	} else {
 		if targetLevel >= LOG_LEVEL_DEBUG && targetLevel < log_level_max {
-			logLevelCtl[logger] = targetLevel
+			setFuncLogLevel(logger, targetLevel)
 			_, err := fmt.Fprintf(w, "操作成功\n")
 			if err != nil {
 				log.Printf("发生预期外错误: %s", err)
 				return
 			}
 		} else {
-			logLevelCtl[logger] = 0
+			setFuncLogLevel(logger, 0)
 			_, err := fmt.Fprintf(w, "传入的level不在有效范围,目标函数仍采取全局日志级别\n")
 			if err != nil {
 				log.Printf("发生预期外错误: %s", err)
@@ -75,14 +107,14 @@ func handleLogLevelCtlGlobal(w http.ResponseWriter, req *http.Request) {
 		}
 	} else {
 		if targetLevel >= LOG_LEVEL_DEBUG && targetLevel < log_level_max {
-			Level = targetLevel
+			defaultLogger.SetLevel(targetLevel)
 			_, err := fmt.Fprintf(w, "操作成功\n")
 			if err != nil {
 				log.Printf("发生预期外错误: %s", err)
 				return
 			}
 		} else {
-			Level = zcgologConfig.LogLevelGlobal
+			defaultLogger.SetLevel(defaultLogger.config.LogLevelGlobal)
 			_, err := fmt.Fprintf(w, "传入的level不在有效范围,全局日志级别恢复为启动配置\n")
 			if err != nil {
 				log.Printf("发生预期外错误: %s", err)
@@ -93,15 +125,16 @@ func handleLogLevelCtlGlobal(w http.ResponseWriter, req *http.Request) {
 }
 
 // 处理日志级别查询请求
+//  logger为函数完整包名路径时，返回按规则匹配后该函数实际采用的日志级别
 func handleLogLevelQuery(w http.ResponseWriter, req *http.Request) {
 	query := req.URL.Query()
 	logger := query.Get("logger")
 	var resultLevel int
 	if logger != "" {
-		resultLevel = logLevelCtl[logger]
+		resultLevel = getFuncLogLevel(logger)
 	}
 	if resultLevel == 0 {
-		resultLevel = Level
+		resultLevel = defaultLogger.GetLevel()
 	}
 	result := GetLogLevelStrByInt(resultLevel)
 	_, err := fmt.Fprint(w, result)
@@ -118,17 +151,41 @@ func handleLogLevelQuery(w http.ResponseWriter, req *http.Request) {
 //  logger是调整目标，对应具体函数的完整包名路径，如: gitee.com/zhaochuninhefei/zcgolog/log.writeLog ;
 //  level是调整后的日志级别，支持从1到6，分别是 DEBUG,INFO,WARNNING,ERROR,CRITICAL,FATAL ;
 //  一个完整的请求URL示例:http://localhost:9300/zcgolog/api/level/ctl?logger=gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog&level=1
-func runLogCtlServe() {
-	listenAddress := zcgologConfig.LogLevelCtlHost + ":" + zcgologConfig.LogLevelCtlPort
-	http.HandleFunc("/zcgolog/api/level/ctl", handleLogLevelCtl)
-	http.HandleFunc("/zcgolog/api/level/global", handleLogLevelCtlGlobal)
-	http.HandleFunc("/zcgolog/api/level/query", handleLogLevelQuery)
+func runLogCtlServe(server *http.Server) {
 	//goland:noinspection HttpUrlsUsage
-	Infof("启动日志级别控制监听服务: [http://%s/zcgolog/api/level/**]", listenAddress)
-	zcgoLogger.Fatal(http.ListenAndServe(listenAddress, nil))
+	Infof("启动日志级别控制监听服务: [http://%s/zcgolog/api/level/**]", server.Addr)
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		Fatalf("日志级别控制监听服务启动失败: %s", err)
+	}
 }
 
-// 异步启动日志级别控制监听服务
+// 异步启动日志级别控制监听服务，已启动时不做处理
 func startLogCtlServe() {
-	go runLogCtlServe()
+	logCtlServerLock.Lock()
+	defer logCtlServerLock.Unlock()
+	if logCtlServer != nil {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/zcgolog/api/level/ctl", handleLogLevelCtl)
+	mux.HandleFunc("/zcgolog/api/level/global", handleLogLevelCtlGlobal)
+	mux.HandleFunc("/zcgolog/api/level/query", handleLogLevelQuery)
+	logCtlServer = &http.Server{
+		Addr:    defaultLogger.config.LogLevelCtlHost + ":" + defaultLogger.config.LogLevelCtlPort,
+		Handler: mux,
+	}
+	go runLogCtlServe(logCtlServer)
+}
+
+// 停止日志级别控制监听服务，未启动时不做处理
+func stopLogCtlServe(ctx context.Context) error {
+	logCtlServerLock.Lock()
+	defer logCtlServerLock.Unlock()
+	if logCtlServer == nil {
+		return nil
+	}
+	server := logCtlServer
+	logCtlServer = nil
+	return server.Shutdown(ctx)
 }
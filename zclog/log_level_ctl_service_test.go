@@ -0,0 +1,111 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// 在线修改日志级别的同时并发输出日志，需配合 go test -race 检查数据竞争
+func TestLogLevelCtlConcurrent(t *testing.T) {
+	fmt.Println("----- TestLogLevelCtlConcurrent -----")
+	// 日志级别控制服务修改的是默认Logger的日志级别，因此通过包级接口输出日志
+	InitLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      t.TempDir(),
+		LogMod:          LOG_MODE_SERVER,
+		LogLevelGlobal:  LOG_LEVEL_INFO,
+		// 使用随机端口，避免端口被占用时监听服务启动失败而终止测试进程
+		LogLevelCtlPort: "0",
+	})
+	// 服务器模式会启动日志级别控制监听服务，测试结束后停止，以免影响后续测试
+	t.Cleanup(func() {
+		_ = stopLogCtlServe(context.Background())
+	})
+	origLevel := GetLevel()
+	caller := "gitee.com/zhaochuninhefei/zcgolog/zclog.TestLogLevelCtlConcurrent.func2"
+	defer func() {
+		SetLevel(origLevel)
+		setFuncLogLevel(caller, 0)
+	}()
+
+	ctlURIs := []string{
+		"/zcgolog/api/level/ctl?logger=" + caller + "&level=1",
+		"/zcgolog/api/level/ctl?logger=" + caller + "&level=7",
+		"/zcgolog/api/level/ctl?logger=gitee.com/zhaochuninhefei/zcgolog/zclog.other&level=4",
+		"/zcgolog/api/level/global?level=4",
+		"/zcgolog/api/level/global?level=2",
+		"/zcgolog/api/level/query?logger=" + caller,
+		"/zcgolog/api/level/query",
+	}
+	handlers := map[string]http.HandlerFunc{
+		"/zcgolog/api/level/ctl":    handleLogLevelCtl,
+		"/zcgolog/api/level/global": handleLogLevelCtlGlobal,
+		"/zcgolog/api/level/query":  handleLogLevelQuery,
+	}
+	var wg sync.WaitGroup
+	// 并发调用日志级别控制接口
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				uri := ctlURIs[(start+j)%len(ctlURIs)]
+				req := httptest.NewRequest(http.MethodGet, uri, nil)
+				handlers[req.URL.Path](httptest.NewRecorder(), req)
+			}
+		}(i)
+	}
+	// 并发输出日志
+	slogger := slog.New(NewSlogHandler(defaultLogger))
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				Debug("并发日志")
+				Infof("并发日志: %d", j)
+				Debugw("并发日志", "seq", j)
+				slogger.Debug("slog并发日志", "seq", j)
+				_ = getFuncLogLevel(caller)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := QuitMsgReader(3000); err != nil {
+		t.Fatal(err)
+	}
+	// 监听停止后继续输出日志，此时直接写入输出目标
+	InfoCtx(context.Background(), "监听停止后输出")
+	defaultLogger.closeCurrentLogFile()
+
+	if GetLevel() != LOG_LEVEL_ERROR && GetLevel() != LOG_LEVEL_INFO {
+		t.Fatalf("全局日志级别不符合预期: %d", GetLevel())
+	}
+	// 已废弃的Level与全局日志级别同步更新
+	//goland:noinspection GoDeprecation
+	if Level != GetLevel() {
+		t.Fatalf("Level应该与全局日志级别同步: %d, %d", Level, GetLevel())
+	}
+	if level := getFuncLogLevel("gitee.com/zhaochuninhefei/zcgolog/zclog.other"); level != LOG_LEVEL_ERROR {
+		t.Fatalf("指定函数的日志级别不符合预期: %d", level)
+	}
+	setFuncLogLevel("gitee.com/zhaochuninhefei/zcgolog/zclog.other", 0)
+	if getFuncLogLevel("gitee.com/zhaochuninhefei/zcgolog/zclog.other") != 0 {
+		t.Fatal("取消指定后函数的日志级别应该为0")
+	}
+}
@@ -0,0 +1,211 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_level_rule.go 指定函数、类型、包或包子树的日志级别控制规则
+*/
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 包子树规则的后缀，如"github.com/ourorg/svc/storage/..."
+const levelRuleSubtreeSuffix = "/..."
+
+// 日志级别控制规则
+//
+//	规则可以是:
+//	 函数，如"gitee.com/zhaochuninhefei/zcgolog/zclog.writeLog"，同时匹配该函数内的闭包；
+//	 类型，如"gitee.com/zhaochuninhefei/zcgolog/zclog.Logger"或"gitee.com/zhaochuninhefei/zcgolog/zclog.(*Logger)"，匹配该类型的所有方法；
+//	 包，如"gitee.com/zhaochuninhefei/zcgolog/zclog"，匹配该包内的所有函数与方法，不含子包；
+//	 包子树，如"gitee.com/zhaochuninhefei/zcgolog/..."，匹配该路径下所有包(含该路径本身)的函数与方法。
+type levelRule struct {
+	// 规则原文
+	name string
+	// 去掉包子树后缀并规范化后的匹配前缀
+	pattern string
+	// 是否是包子树规则
+	subtree bool
+	// 日志级别
+	level int
+}
+
+// 判断函数是否匹配该规则，funcName需要已经规范化，pkgLen为函数所在包路径的长度
+func (r *levelRule) match(funcName string, pkgLen int) bool {
+	if !strings.HasPrefix(funcName, r.pattern) {
+		return false
+	}
+	rest := funcName[len(r.pattern):]
+	if rest == "" {
+		return true
+	}
+	switch rest[0] {
+	case '.':
+		// 包、类型或函数的成员，规则需要覆盖函数所在的完整包路径，
+		// 防止规则"gopkg.in/yaml"匹配到包"gopkg.in/yaml.v3"或规则"a/b"匹配到包"a/b.c/d"
+		return len(r.pattern) >= pkgLen
+	case '[':
+		// 泛型函数或泛型类型的方法
+		return true
+	case '/':
+		return r.subtree
+	}
+	return false
+}
+
+// 包路径最后一段中的"."在运行时函数名中被转义为"%2e"，如"gopkg.in/yaml%2ev3.Unmarshal"
+const funcNameEscapedDot = "%2e"
+
+// 取得运行时函数名中的包路径，即最后一个"/"之后第一个"."之前的部分，并还原其中转义的"."
+//
+//	如"gopkg.in/yaml%2ev3.Unmarshal"的包路径为"gopkg.in/yaml.v3"
+func funcPackagePath(funcName string) string {
+	slash := strings.LastIndexByte(funcName, '/')
+	if dot := strings.IndexByte(funcName[slash+1:], '.'); dot >= 0 {
+		funcName = funcName[:slash+1+dot]
+	}
+	return strings.ReplaceAll(funcName, funcNameEscapedDot, ".")
+}
+
+// 规范化函数名，还原包路径中转义的"."，并将指针接收者方法名中的"(*T)"替换为"T"，使类型规则同时匹配值接收者与指针接收者方法
+//
+//	如"a/b.(*T).Method"规范化为"a/b.T.Method"，"a/b%2ev3.F"规范化为"a/b.v3.F"
+func normalizeFuncName(funcName string) string {
+	funcName = strings.ReplaceAll(funcName, funcNameEscapedDot, ".")
+	i := strings.Index(funcName, ".(*")
+	if i < 0 {
+		return funcName
+	}
+	j := strings.IndexByte(funcName[i:], ')')
+	if j < 0 {
+		return funcName
+	}
+	return funcName[:i+1] + funcName[i+3:i+j] + funcName[i+j+1:]
+}
+
+// 解析日志级别控制规则
+func newLevelRule(name string, level int) levelRule {
+	rule := levelRule{name: name, pattern: name, level: level}
+	if strings.HasSuffix(name, levelRuleSubtreeSuffix) {
+		rule.pattern = strings.TrimSuffix(name, levelRuleSubtreeSuffix)
+		rule.subtree = true
+	}
+	rule.pattern = normalizeFuncName(rule.pattern)
+	return rule
+}
+
+// 日志级别控制表
+//
+//	创建后不再修改，修改规则时复制出新的控制表再原子替换，日志输出时无锁读取；
+//	调用方函数的匹配结果按调用处的程序计数器缓存在控制表中，规则变化时随控制表一起丢弃。
+type logLevelCtlTable struct {
+	// 规则原文与日志级别的对应关系
+	levels map[string]int
+	// 按匹配前缀从长到短排序的规则，同样长度时函数、类型与包规则优先于包子树规则
+	rules []levelRule
+	// 所有规则中最低的日志级别，没有任何规则时为0
+	minLevel int
+	// 调用处程序计数器与匹配到的日志级别的缓存，map[uintptr]int
+	callerLevels sync.Map
+}
+
+// 按最长匹配查找函数对应的日志级别，没有匹配的规则时返回0，funcName为运行时函数名
+func (t *logLevelCtlTable) resolve(funcName string) int {
+	pkgLen := len(funcPackagePath(funcName))
+	funcName = normalizeFuncName(funcName)
+	for i := range t.rules {
+		if t.rules[i].match(funcName, pkgLen) {
+			return t.rules[i].level
+		}
+	}
+	return 0
+}
+
+// 日志级别控制
+var logLevelCtl atomic.Pointer[logLevelCtlTable]
+
+// 修改日志级别控制规则时使用的锁
+var logLevelCtlLock sync.Mutex
+
+// 获取调用处对应的日志级别，没有匹配的规则时返回0
+//
+//	pc 调用处的程序计数器，同一调用处只在第一次输出日志时做规则匹配
+func getCallerLogLevel(pc uintptr) int {
+	table := logLevelCtl.Load()
+	if table == nil || len(table.rules) == 0 {
+		return 0
+	}
+	if level, ok := table.callerLevels.Load(pc); ok {
+		return level.(int)
+	}
+	// 通过CallersFrames获取函数名，调用处位于内联函数中时也能得到正确的调用方函数
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	level := table.resolve(frame.Function)
+	table.callerLevels.Store(pc, level)
+	return level
+}
+
+// 获取指定函数的日志级别，没有匹配的规则时返回0
+func getFuncLogLevel(callFunc string) int {
+	table := logLevelCtl.Load()
+	if table == nil {
+		return 0
+	}
+	return table.resolve(callFunc)
+}
+
+// 获取所有规则中最低的日志级别，没有任何规则时返回0
+func minFuncLogLevel() int {
+	table := logLevelCtl.Load()
+	if table == nil {
+		return 0
+	}
+	return table.minLevel
+}
+
+// 设置函数、类型、包或包子树的日志级别，level为0时表示删除该规则，恢复采用全局日志级别
+func setFuncLogLevel(ruleName string, level int) {
+	logLevelCtlLock.Lock()
+	defer logLevelCtlLock.Unlock()
+	newTable := &logLevelCtlTable{levels: map[string]int{}}
+	if current := logLevelCtl.Load(); current != nil {
+		for k, v := range current.levels {
+			newTable.levels[k] = v
+		}
+	}
+	if level == 0 {
+		delete(newTable.levels, ruleName)
+	} else {
+		newTable.levels[ruleName] = level
+	}
+	for k, v := range newTable.levels {
+		newTable.rules = append(newTable.rules, newLevelRule(k, v))
+		if newTable.minLevel == 0 || v < newTable.minLevel {
+			newTable.minLevel = v
+		}
+	}
+	sort.Slice(newTable.rules, func(i, j int) bool {
+		ri, rj := newTable.rules[i], newTable.rules[j]
+		if len(ri.pattern) != len(rj.pattern) {
+			return len(ri.pattern) > len(rj.pattern)
+		}
+		if ri.subtree != rj.subtree {
+			return !ri.subtree
+		}
+		return ri.name < rj.name
+	})
+	logLevelCtl.Store(newTable)
+}
@@ -0,0 +1,136 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLevelRuleResolve(t *testing.T) {
+	fmt.Println("----- TestLevelRuleResolve -----")
+	rules := map[string]int{
+		"github.com/ourorg/svc/...":                LOG_LEVEL_ERROR,
+		"github.com/ourorg/svc/storage/...":        LOG_LEVEL_DEBUG,
+		"github.com/ourorg/svc/storage":            LOG_LEVEL_WARNING,
+		"github.com/ourorg/svc/storage.(*Store)":   LOG_LEVEL_INFO,
+		"github.com/ourorg/svc/storage.Store.Save": LOG_LEVEL_FATAL,
+		"github.com/ourorg/yaml":                   LOG_LEVEL_PANIC,
+		"gopkg.in/yaml":                            LOG_LEVEL_WARNING,
+		"gopkg.in/yaml.v2":                         LOG_LEVEL_INFO,
+	}
+	for name, level := range rules {
+		setFuncLogLevel(name, level)
+	}
+	defer func() {
+		for name := range rules {
+			setFuncLogLevel(name, 0)
+		}
+	}()
+	cases := map[string]int{
+		// 包子树
+		"github.com/ourorg/svc.main":           LOG_LEVEL_ERROR,
+		"github.com/ourorg/svc/api.Handle":     LOG_LEVEL_ERROR,
+		"github.com/ourorg/svc/storage/kv.Get": LOG_LEVEL_DEBUG,
+		// 包优先于同样前缀的包子树
+		"github.com/ourorg/svc/storage.Open":       LOG_LEVEL_WARNING,
+		"github.com/ourorg/svc/storage.Open.func1": LOG_LEVEL_WARNING,
+		"github.com/ourorg/svc/storage.Map[...]":   LOG_LEVEL_WARNING,
+		// 类型的值接收者与指针接收者方法
+		"github.com/ourorg/svc/storage.(*Store).Load": LOG_LEVEL_INFO,
+		"github.com/ourorg/svc/storage.Store.String":  LOG_LEVEL_INFO,
+		// 函数规则最长，优先匹配
+		"github.com/ourorg/svc/storage.(*Store).Save":       LOG_LEVEL_FATAL,
+		"github.com/ourorg/svc/storage.(*Store).Save.func2": LOG_LEVEL_FATAL,
+		// 不匹配
+		"github.com/ourorg/svcx.main":           0,
+		"github.com/ourorg/svc/storagex.Open":   LOG_LEVEL_ERROR,
+		"github.com/ourorg/yaml.v3/parser.New":  0,
+		"github.com/ourorg/yaml%2ev3.Unmarshal": 0,
+		"gopkg.in/yaml%2ev3.F":                  0,
+		"gopkg.in/yaml%2ev3.(*Decoder).Decode":  0,
+		"github.com/ourorg/yaml.Unmarshal":      LOG_LEVEL_PANIC,
+		"gopkg.in/yaml.F":                       LOG_LEVEL_WARNING,
+		// 包路径最后一段中的"."在运行时函数名中转义为"%2e"
+		"gopkg.in/yaml%2ev2.F":                 LOG_LEVEL_INFO,
+		"gopkg.in/yaml%2ev2.(*Decoder).Decode": LOG_LEVEL_INFO,
+	}
+	for funcName, expected := range cases {
+		if level := getFuncLogLevel(funcName); level != expected {
+			t.Fatalf("函数 %s 的日志级别应该为 %d, 实际为 %d", funcName, expected, level)
+		}
+	}
+	if minFuncLogLevel() != LOG_LEVEL_DEBUG {
+		t.Fatalf("最低的规则日志级别应该为DEBUG: %d", minFuncLogLevel())
+	}
+	setFuncLogLevel("github.com/ourorg/svc/storage/...", 0)
+	if minFuncLogLevel() != LOG_LEVEL_INFO || getFuncLogLevel("github.com/ourorg/svc/storage/kv.Get") != LOG_LEVEL_ERROR {
+		t.Fatal("删除规则后应该匹配次长的规则")
+	}
+}
+
+type ruleTestType struct{}
+
+func (*ruleTestType) callerPC() uintptr {
+	pc, _, _, _ := runtime.Caller(0)
+	return pc
+}
+
+func TestCallerLogLevelCache(t *testing.T) {
+	fmt.Println("----- TestCallerLogLevelCache -----")
+	pc := (&ruleTestType{}).callerPC()
+	if getCallerLogLevel(pc) != 0 {
+		t.Fatal("没有规则时调用处的日志级别应该为0")
+	}
+	typeRule := "gitee.com/zhaochuninhefei/zcgolog/zclog.ruleTestType"
+	setFuncLogLevel(typeRule, LOG_LEVEL_WARNING)
+	defer setFuncLogLevel(typeRule, 0)
+	if getCallerLogLevel(pc) != LOG_LEVEL_WARNING {
+		t.Fatal("类型规则应该匹配指针接收者方法")
+	}
+	if level, ok := logLevelCtl.Load().callerLevels.Load(pc); !ok || level.(int) != LOG_LEVEL_WARNING {
+		t.Fatal("匹配结果应该按调用处缓存")
+	}
+	// 规则变化后缓存失效
+	pkgRule := "gitee.com/zhaochuninhefei/zcgolog/..."
+	setFuncLogLevel(pkgRule, LOG_LEVEL_DEBUG)
+	defer setFuncLogLevel(pkgRule, 0)
+	setFuncLogLevel(typeRule, 0)
+	if getCallerLogLevel(pc) != LOG_LEVEL_DEBUG {
+		t.Fatal("规则变化后应该重新匹配")
+	}
+}
+
+func TestPackageLevelRuleLog(t *testing.T) {
+	fmt.Println("----- TestPackageLevelRuleLog -----")
+	logger, err := NewLogger(&Config{LogLevelGlobal: LOG_LEVEL_WARNING, LogLineFormat: "%level %msg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger.stdLogger.SetOutput(&buf)
+	logger.stdLogger.SetFlags(0)
+
+	logger.Info("包规则之前不输出")
+	pkgRule := "gitee.com/zhaochuninhefei/zcgolog/zclog"
+	setFuncLogLevel(pkgRule, LOG_LEVEL_DEBUG)
+	logger.Debug("包规则生效后输出")
+	setFuncLogLevel(pkgRule, 0)
+	logger.Info("包规则删除后不输出")
+
+	fmt.Print(buf.String())
+	if strings.TrimSpace(buf.String()) != "[DEBUG] 包规则生效后输出" {
+		t.Fatalf("日志输出不符合预期: %s", buf.String())
+	}
+}
@@ -0,0 +1,409 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_net_sink.go 网络输出目标，通过TCP(可选TLS)将JSON行格式的日志发送到日志收集服务，
+无法连接时缓存到本地文件，恢复连接后按顺序重放
+*/
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// 重放本地缓存时每次发送的最大字节数
+const netSpoolReplayBatchSize = 64 * 1024
+
+// NetSinkConfig 网络输出目标配置
+type NetSinkConfig struct {
+	// 日志收集服务地址，如"10.0.0.1:5170"
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+	// 是否使用TLS连接，默认: false
+	TLS bool `json:"tls" yaml:"tls" mapstructure:"tls"`
+	// TLS校验的服务端名称，默认取Address中的主机名
+	TLSServerName string `json:"tls_server_name" yaml:"tls_server_name" mapstructure:"tls_server_name"`
+	// 用于校验服务端证书的CA证书文件(PEM格式)，默认使用系统CA证书
+	TLSCAFile string `json:"tls_ca_file" yaml:"tls_ca_file" mapstructure:"tls_ca_file"`
+	// 自定义TLS配置，配置后忽略TLSServerName与TLSCAFile
+	TLSConfig *tls.Config `json:"-" yaml:"-" mapstructure:"-"`
+	// 本地缓存文件路径，通过LogSinks配置时默认为 [LogFileDir]/[LogFileNamePrefix]_net.spool ；
+	// 通过NewNetSink创建时为空表示不缓存，无法连接时丢弃日志
+	SpoolFile string `json:"spool_file" yaml:"spool_file" mapstructure:"spool_file"`
+	// 本地缓存文件大小上限，单位M，默认: 100，超出后丢弃新的日志
+	SpoolMaxSizeM int `json:"spool_max_size_m" yaml:"spool_max_size_m" mapstructure:"spool_max_size_m"`
+	// 连接失败后的最小重连间隔，单位毫秒，默认: 100
+	ReconnectMinMilliSec int `json:"reconnect_min_milli_sec" yaml:"reconnect_min_milli_sec" mapstructure:"reconnect_min_milli_sec"`
+	// 连接失败后的最大重连间隔，单位毫秒，默认: 30000
+	ReconnectMaxMilliSec int `json:"reconnect_max_milli_sec" yaml:"reconnect_max_milli_sec" mapstructure:"reconnect_max_milli_sec"`
+}
+
+// 检查网络输出目标配置
+func checkNetSinkConfig(netConfig *NetSinkConfig) error {
+	if netConfig == nil {
+		return fmt.Errorf("网络输出目标配置不可为空")
+	}
+	if _, _, err := net.SplitHostPort(netConfig.Address); err != nil {
+		return fmt.Errorf("日志收集服务地址不合法: %s, %w", netConfig.Address, err)
+	}
+	if netConfig.SpoolMaxSizeM < 0 || netConfig.ReconnectMinMilliSec < 0 || netConfig.ReconnectMaxMilliSec < 0 {
+		return fmt.Errorf("网络输出目标的本地缓存大小上限与重连间隔不能小于0")
+	}
+	return nil
+}
+
+// 网络输出目标的默认本地缓存文件路径
+func defaultNetSpoolFile(logConfig *Config) string {
+	return defaultSpoolFile(logConfig, "net")
+}
+
+// 重连的指数退避控制，syslog、网络与HTTP输出目标共用
+type reconnectBackoff struct {
+	// 最小与最大重连间隔
+	min, max time.Duration
+	// 当前重连间隔
+	cur time.Duration
+	// 下次允许重连的时间
+	next time.Time
+}
+
+func newReconnectBackoff(minMilliSec, maxMilliSec int) reconnectBackoff {
+	if minMilliSec == 0 {
+		minMilliSec = 100
+	}
+	if maxMilliSec == 0 {
+		maxMilliSec = 30000
+	}
+	b := reconnectBackoff{min: time.Duration(minMilliSec) * time.Millisecond, max: time.Duration(maxMilliSec) * time.Millisecond}
+	b.cur = b.min
+	return b
+}
+
+// 是否已到达允许重连的时间
+func (b *reconnectBackoff) ready() bool {
+	return !time.Now().Before(b.next)
+}
+
+// 连接失败，按当前间隔推迟下次重连时间，并将间隔加倍(不超过最大间隔)
+func (b *reconnectBackoff) fail() {
+	b.next = time.Now().Add(b.cur)
+	b.cur *= 2
+	if b.cur > b.max {
+		b.cur = b.max
+	}
+}
+
+// 连接成功，重置重连间隔
+func (b *reconnectBackoff) reset() {
+	b.cur = b.min
+	b.next = time.Time{}
+}
+
+// 网络输出目标
+//
+//	每条日志编码为一行JSON，以换行分隔。连接断开或无法连接时，日志追加到本地缓存文件，
+//	由后台goroutine按指数退避间隔重连，并在重新连接后按最小重连间隔定时重放本地缓存，Write不会等待重连与重放。
+//	本地缓存未重放完之前新的日志同样追加到缓存，全部重放后恢复直接发送，保证顺序。
+//	重放过程中连接断开时，之后从最后一批已发送成功的日志之后继续重放，因此日志收集服务可能收到重复的日志。
+//	发送过程中连接断开时，已发出的部分日志行会作为该连接上不完整的最后一行到达日志收集服务，
+//	该行日志完整地追加到本地缓存，在新的连接上从行首重新发送。
+type netSink struct {
+	lock      sync.Mutex
+	config    NetSinkConfig
+	logConfig *Config
+	tlsConfig *tls.Config
+	// 编码缓冲区，写入时复用
+	buf []byte
+
+	// 当前连接，未连接时为nil
+	conn    net.Conn
+	backoff reconnectBackoff
+	closed  bool
+
+	// 本地缓存文件，不缓存时为nil
+	spool *logSpool
+	// 本地缓存已满或无法缓存而丢弃的日志数量
+	dropped int
+	// Close时关闭，用于停止后台重连与重放goroutine
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewNetSink 创建网络输出目标
+//
+//	日志收集服务暂时无法连接时不会返回错误，之后由后台goroutine按退避间隔重连；
+//	配置了SpoolFile时，本地缓存文件采用默认的日志文件权限。
+func NewNetSink(netConfig *NetSinkConfig) (Sink, error) {
+	return newNetSink(netConfig, newDefaultConfig())
+}
+
+// 创建网络输出目标，logConfig用于本地缓存文件的权限与属组
+func newNetSink(netConfig *NetSinkConfig, logConfig *Config) (*netSink, error) {
+	if err := checkNetSinkConfig(netConfig); err != nil {
+		return nil, err
+	}
+	s := &netSink{config: *netConfig, logConfig: logConfig}
+	if s.config.SpoolMaxSizeM == 0 {
+		s.config.SpoolMaxSizeM = 100
+	}
+	s.backoff = newReconnectBackoff(s.config.ReconnectMinMilliSec, s.config.ReconnectMaxMilliSec)
+	if s.config.TLS {
+		tlsConfig, err := s.newTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		s.tlsConfig = tlsConfig
+	}
+	if s.config.SpoolFile != "" {
+		spool, err := openLogSpool(s.config.SpoolFile, s.config.SpoolMaxSizeM, logConfig)
+		if err != nil {
+			return nil, err
+		}
+		s.spool = spool
+	}
+	if conn, err := s.dial(); err == nil {
+		s.conn = conn
+	} else {
+		s.backoff.fail()
+	}
+	s.closing = make(chan struct{})
+	s.wg.Add(1)
+	go s.runReplay()
+	return s, nil
+}
+
+// 根据配置生成TLS配置
+func (s *netSink) newTLSConfig() (*tls.Config, error) {
+	if s.config.TLSConfig != nil {
+		return s.config.TLSConfig.Clone(), nil
+	}
+	tlsConfig := &tls.Config{ServerName: s.config.TLSServerName, MinVersion: tls.VersionTLS12}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(s.config.Address)
+	}
+	if s.config.TLSCAFile != "" {
+		caPem, err := os.ReadFile(s.config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书文件发生错误: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPem) {
+			return nil, fmt.Errorf("CA证书文件中没有有效的PEM证书: %s", s.config.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// 连接日志收集服务，不需要持有lock，连接期间Write不会被阻塞
+func (s *netSink) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	var conn net.Conn
+	var err error
+	if s.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.config.Address, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", s.config.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接日志收集服务发生错误: %w", err)
+	}
+	return conn, nil
+}
+
+// 未连接且已到达允许重连的时间时重新连接，由后台goroutine调用，返回是否已连接
+//
+//	只在检查与更新连接状态时持有lock，连接期间不持有lock。
+func (s *netSink) reconnect() bool {
+	s.lock.Lock()
+	if s.closed || s.conn != nil || !s.backoff.ready() {
+		connected := s.conn != nil
+		s.lock.Unlock()
+		return connected
+	}
+	s.lock.Unlock()
+	conn, err := s.dial()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		s.backoff.fail()
+		return false
+	}
+	if s.closed {
+		_ = conn.Close()
+		return false
+	}
+	s.conn = conn
+	s.backoff.reset()
+	return true
+}
+
+// 断开连接并按退避间隔推迟重连，需要在持有lock时调用
+func (s *netSink) disconnect() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	s.backoff.fail()
+}
+
+// 通过连接发送数据
+func sendNet(conn net.Conn, data []byte) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(syslogDialTimeout))
+	_, err := conn.Write(data)
+	return err
+}
+
+// 本地缓存中是否有尚未重放的日志，需要在持有lock时调用
+func (s *netSink) spoolPending() bool {
+	return s.spool != nil && s.spool.pending()
+}
+
+// 后台重连与重放本地缓存，按最小重连间隔定时执行，Close后退出
+func (s *netSink) runReplay() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.backoff.min)
+	defer ticker.Stop()
+	var buf []byte
+	for {
+		if s.reconnect() {
+			buf = s.replaySpool(buf)
+		}
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 按顺序重放本地缓存中的日志，由后台goroutine在已连接时调用
+//
+//	每次在持有lock时读取若干完整的日志行，释放lock后发送，发送成功后才推进重放位置；
+//	重放期间Write只向本地缓存追加日志，不会同时使用连接发送。全部重放后清空缓存文件。
+func (s *netSink) replaySpool(buf []byte) []byte {
+	for {
+		s.lock.Lock()
+		if s.closed || s.conn == nil {
+			s.lock.Unlock()
+			return buf
+		}
+		if !s.spoolPending() {
+			if s.dropped > 0 {
+				fmt.Printf("zclog 日志收集服务已重新连接，期间丢弃日志 %d 条\n", s.dropped)
+				s.dropped = 0
+			}
+			s.lock.Unlock()
+			return buf
+		}
+		conn := s.conn
+		var err error
+		buf, _, err = s.spool.read(buf[:0], netSpoolReplayBatchSize, 0)
+		s.lock.Unlock()
+		if err == nil && len(buf) > 0 {
+			err = sendNet(conn, buf)
+		}
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			return buf
+		}
+		if err == nil {
+			err = s.spool.advance(len(buf))
+		}
+		if err != nil {
+			if s.conn == conn {
+				s.disconnect()
+			}
+			s.lock.Unlock()
+			return buf
+		}
+		s.lock.Unlock()
+	}
+}
+
+// 将日志追加到本地缓存，需要在持有lock时调用
+func (s *netSink) appendSpool(data []byte) error {
+	if s.spool == nil {
+		s.dropped++
+		return nil
+	}
+	ok, err := s.spool.append(data)
+	if !ok {
+		s.dropped++
+		if s.dropped == 1 {
+			return fmt.Errorf("网络输出目标的本地缓存已满，之后的日志将被丢弃直到恢复连接: %s", s.config.SpoolFile)
+		}
+	}
+	return err
+}
+
+// Write 发送一条日志，未连接或本地缓存未重放完时追加到本地缓存
+//
+//	Write不会连接日志收集服务，重连由后台goroutine完成，日志收集服务不可用时Write也不会阻塞。
+func (s *netSink) Write(entry *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.buf = jsonEncoder{}.encode(s.buf[:0], entry.encodeMsg())
+	s.buf = append(s.buf, '\n')
+	// 本地缓存未重放完之前追加到缓存，由后台goroutine按顺序重放
+	if s.conn != nil && !s.spoolPending() {
+		if err := sendNet(s.conn, s.buf); err == nil {
+			return nil
+		}
+		// 发送失败时该行日志可能已有一部分发出，断开连接后日志收集服务收到的是不完整的最后一行；
+		// 完整的日志行追加到本地缓存，重新连接后从新连接的行首开始重放
+		s.disconnect()
+	}
+	return s.appendSpool(s.buf)
+}
+
+// Sync 将本地缓存文件落盘
+func (s *netSink) Sync() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.spool == nil {
+		return nil
+	}
+	return s.spool.sync()
+}
+
+// Close 停止后台重放，关闭连接与本地缓存文件，本地缓存为空时删除缓存文件
+func (s *netSink) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	var errs []error
+	// 先关闭连接，中断正在进行的重放发送
+	if s.conn != nil {
+		errs = append(errs, s.conn.Close())
+		s.conn = nil
+	}
+	s.lock.Unlock()
+	close(s.closing)
+	s.wg.Wait()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.spool != nil {
+		errs = append(errs, s.spool.close())
+		s.spool = nil
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,289 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 测试用的日志收集服务，按行接收日志
+func startTestCollector(t *testing.T, listener net.Listener) <-chan map[string]any {
+	lines := make(chan map[string]any, 1000)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					var line map[string]any
+					if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+						t.Errorf("日志收集服务收到的日志不是JSON行: %s", scanner.Text())
+						return
+					}
+					lines <- line
+				}
+			}()
+		}
+	}()
+	return lines
+}
+
+// 从日志收集服务接收一条日志
+func receiveLine(t *testing.T, lines <-chan map[string]any) map[string]any {
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(3 * time.Second):
+		t.Fatal("日志收集服务未收到日志")
+		return nil
+	}
+}
+
+func TestNetSink(t *testing.T) {
+	fmt.Println("----- TestNetSink -----")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	lines := startTestCollector(t, listener)
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      logDir,
+		LogMod:          LOG_MODE_SERVER,
+		LogSinks: []SinkConfig{{
+			Type:  LOG_SINK_NET,
+			Level: LOG_LEVEL_WARNING,
+			Net:   &NetSinkConfig{Address: listener.Addr().String()},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("低于最低日志级别的日志")
+	logger.Warnw("用户登录失败", "user", "zhangsan")
+	logger.Errorf("第%d行\n第%d行", 1, 2)
+	line := receiveLine(t, lines)
+	if line["level"] != "warning" || line["msg"] != "用户登录失败" || line["user"] != "zhangsan" {
+		t.Fatalf("日志收集服务收到的日志不符合预期: %v", line)
+	}
+	// 日志中的换行已转义，不影响按行分帧
+	if line = receiveLine(t, lines); line["level"] != "error" || line["msg"] != "第1行\n第2行" {
+		t.Fatalf("日志收集服务收到的日志不符合预期: %v", line)
+	}
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 本地缓存为空时关闭后删除缓存文件
+	if _, err := os.Stat(defaultNetSpoolFile(logger.config)); !os.IsNotExist(err) {
+		t.Fatalf("本地缓存为空时关闭后应该删除缓存文件: %v", err)
+	}
+	if _, err := os.Stat(defaultNetSpoolFile(logger.config) + spoolOffsetFileExt); !os.IsNotExist(err) {
+		t.Fatalf("本地缓存为空时关闭后应该删除重放位置文件: %v", err)
+	}
+
+	// 网络输出目标配置校验
+	invalidSinks := [][]SinkConfig{
+		{{Type: LOG_SINK_NET}},
+		{{Type: LOG_SINK_NET, Net: &NetSinkConfig{Address: "127.0.0.1"}}},
+		{{Type: LOG_SINK_NET, Net: &NetSinkConfig{Address: "127.0.0.1:5170", SpoolMaxSizeM: -1}}},
+		{{Type: LOG_SINK_NET, Net: &NetSinkConfig{Address: "127.0.0.1:5170"}}, {Type: LOG_SINK_NET, Net: &NetSinkConfig{Address: "127.0.0.1:5171"}}},
+	}
+	for i, sinks := range invalidSinks {
+		config := newDefaultConfig()
+		config.LogFileDir = logDir
+		config.LogSinks = sinks
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("第%d组网络输出目标配置应该校验失败", i+1)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	config := newDefaultConfig()
+	config.LogSinks = []SinkConfig{{Type: LOG_SINK_NET, Net: &NetSinkConfig{Address: "127.0.0.1:5170"}}}
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("未配置本地缓存文件且日志目录为空时应该校验失败")
+	}
+}
+
+func TestNetSinkSpool(t *testing.T) {
+	fmt.Println("----- TestNetSinkSpool -----")
+	// 获取一个暂时没有监听的端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	spoolFile := path.Join(t.TempDir(), "spool", "net.spool")
+	sink, err := newNetSink(&NetSinkConfig{Address: addr, SpoolFile: spoolFile, ReconnectMinMilliSec: 50, ReconnectMaxMilliSec: 50}, newDefaultConfig())
+	if err != nil {
+		t.Fatal("日志收集服务无法连接时不应该返回错误")
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	for i := 0; i < 100; i++ {
+		_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, strconv.Itoa(i)))
+	}
+	if !netSpoolPending(sink) {
+		t.Fatal("日志收集服务无法连接时日志应该写入本地缓存")
+	}
+
+	// 日志收集服务恢复后，不需要新的日志，后台按顺序重放本地缓存
+	listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("无法重新监听端口 %s: %s", addr, err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	lines := startTestCollector(t, listener)
+	for i := 0; i < 100; i++ {
+		if line := receiveLine(t, lines); line["msg"] != strconv.Itoa(i) {
+			t.Fatalf("第%d条日志不符合预期: %v", i+1, line)
+		}
+	}
+	// 重放完毕后新的日志直接发送
+	if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "100")); err != nil {
+		t.Fatal(err)
+	}
+	if line := receiveLine(t, lines); line["msg"] != "100" {
+		t.Fatalf("重放完毕后的日志不符合预期: %v", line)
+	}
+	fileStat, err := os.Stat(spoolFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileStat.Size() != 0 || netSpoolPending(sink) {
+		t.Fatalf("本地缓存重放后应该清空: size=%d", fileStat.Size())
+	}
+}
+
+// 加锁读取网络输出目标的本地缓存中是否有尚未重放的日志
+func netSpoolPending(sink *netSink) bool {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	return sink.spoolPending()
+}
+
+func TestNetSinkSpoolFull(t *testing.T) {
+	fmt.Println("----- TestNetSinkSpoolFull -----")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	sink, err := newNetSink(&NetSinkConfig{Address: addr, SpoolFile: path.Join(t.TempDir(), "net.spool"), ReconnectMinMilliSec: 60000}, newDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	sink.spool.maxSize = 1024
+	var errCount int
+	for i := 0; i < 100; i++ {
+		if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "本地缓存已满时丢弃的日志")); err != nil {
+			errCount++
+		}
+	}
+	// 本地缓存已满时只在第一次丢弃日志时返回错误
+	if sink.spool.size > sink.spool.maxSize || sink.dropped == 0 || errCount != 1 {
+		t.Fatalf("本地缓存已满时的状态不符合预期: size=%d, dropped=%d, errCount=%d", sink.spool.size, sink.dropped, errCount)
+	}
+}
+
+// 生成测试用的自签名证书
+func newTestCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "zcgolog-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestNetSinkTLS(t *testing.T) {
+	fmt.Println("----- TestNetSinkTLS -----")
+	cert := newTestCertificate(t)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	lines := startTestCollector(t, listener)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(cert.Leaf)
+	sink, err := NewNetSink(&NetSinkConfig{
+		Address:   listener.Addr().String(),
+		TLS:       true,
+		TLSConfig: &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	if err := sink.Write(newTestEntry(LOG_LEVEL_WARNING, "TLS日志")); err != nil {
+		t.Fatal(err)
+	}
+	if line := receiveLine(t, lines); line["level"] != "warning" || line["msg"] != "TLS日志" {
+		t.Fatalf("TLS连接收到的日志不符合预期: %v", line)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_reopen.go 当前日志文件的固定路径软链接，以及日志文件的重新打开
+*/
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+)
+
+// 获取指向当前日志文件的软链接路径: [LogFileDir]/[LogFileNamePrefix][LogFileExt]，默认如 zcgolog.log
+func logFileSymlinkPath(logConfig *Config) string {
+	return path.Join(logConfig.LogFileDir, logConfig.LogFileNamePrefix+logConfig.LogFileExt)
+}
+
+// 将软链接指向当前日志文件
+//
+//	先在临时路径创建软链接再重命名覆盖，保证软链接的更新是原子的；
+//	软链接使用相对路径，日志目录整体移动后仍然有效。
+//	软链接路径上已存在非软链接的文件时返回错误，不会覆盖该文件。
+func updateLogFileSymlink(logConfig *Config, logFilePath string) error {
+	linkPath := logFileSymlinkPath(logConfig)
+	if fileInfo, err := os.Lstat(linkPath); err == nil && fileInfo.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("无法创建指向当前日志文件的软链接, 已存在同名文件: %s", linkPath)
+	}
+	tmpPath := path.Join(logConfig.LogFileDir, "."+logConfig.LogFileNamePrefix+logConfig.LogFileExt+".tmp")
+	_ = os.Remove(tmpPath)
+	if err := os.Symlink(path.Base(logFilePath), tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, linkPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Reopen 重新打开默认Logger的日志文件
+func Reopen() error {
+	return defaultLogger.Reopen()
+}
+
+// Reopen 重新打开日志文件
+//
+//	关闭当前日志文件后，重新获取当前滚动周期的最新日志文件并打开。
+//	用于配合外部的logrotate等工具：当前日志文件被移走后调用该方法，之后的日志写入新的日志文件，无需重启程序。
+//	配置了日志文件类型的日志输出目标或错误日志文件时，同时重新打开这些日志文件。
+//	没有打开日志文件(如LogFileDir为空或已经Shutdown)时不做处理。
+func (l *Logger) Reopen() error {
+	return errors.Join(l.fileWriter.reopen(), l.reopenSinks(), l.reopenErrorFile())
+}
+
+// 根据配置启动或停止SIGHUP信号监听
+//
+//	LogReopenOnSIGHUP为true时，收到SIGHUP信号后重新打开日志文件。
+func (l *Logger) setupSighupHandler() {
+	l.loggerLock.Lock()
+	defer l.loggerLock.Unlock()
+	if !l.config.LogReopenOnSIGHUP {
+		l.stopSighupHandlerLocked()
+		return
+	}
+	if l.sighupChn != nil {
+		return
+	}
+	l.sighupChn = make(chan os.Signal, 1)
+	signal.Notify(l.sighupChn, syscall.SIGHUP)
+	go func(sighupChn chan os.Signal) {
+		for range sighupChn {
+			if err := l.Reopen(); err != nil {
+				fmt.Printf("zclog 收到SIGHUP信号后重新打开日志文件发生错误: %s\n", err)
+			}
+		}
+	}(l.sighupChn)
+}
+
+// 停止SIGHUP信号监听
+func (l *Logger) stopSighupHandler() {
+	l.loggerLock.Lock()
+	defer l.loggerLock.Unlock()
+	l.stopSighupHandlerLocked()
+}
+
+// 停止SIGHUP信号监听，需要在持有loggerLock时调用
+func (l *Logger) stopSighupHandlerLocked() {
+	if l.sighupChn == nil {
+		return
+	}
+	signal.Stop(l.sighupChn)
+	close(l.sighupChn)
+	l.sighupChn = nil
+}
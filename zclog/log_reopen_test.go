@@ -0,0 +1,151 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLogFileSymlink(t *testing.T) {
+	fmt.Println("----- TestLogFileSymlink -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout: true,
+		LogFileDir:      logDir,
+		LogMod:          LOG_MODE_LOCAL,
+		LogFileMaxSizeM: 1,
+		LogFileSymlink:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = logger.Shutdown(context.Background())
+	}()
+	linkPath := path.Join(logDir, "zcgolog.log")
+	checkSymlink := func() {
+		target, err := os.Readlink(linkPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != logger.fileWriter.fileName() {
+			t.Fatalf("软链接应该指向当前日志文件: %s, %s", target, logger.fileWriter.fileName())
+		}
+	}
+	checkSymlink()
+	firstFile := logger.fileWriter.fileName()
+	msg := strings.Repeat("测试日志", 64)
+	for i := 0; i < 2000; i++ {
+		logger.Info(msg)
+	}
+	if logger.fileWriter.fileName() == firstFile {
+		t.Fatal("日志文件应该已经滚动")
+	}
+	checkSymlink()
+	// 通过软链接可以读取到最新的日志
+	logger.Info("通过软链接读取的日志")
+	content, err := os.ReadFile(linkPath)
+	if err != nil || !strings.Contains(string(content), "通过软链接读取的日志") {
+		t.Fatal("通过软链接应该能读取当前日志文件")
+	}
+	// 软链接不影响日志文件序列号计算与保留策略
+	logFiles, err := listLogFiles(logger.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range logFiles {
+		if f.name == "zcgolog.log" {
+			t.Fatal("软链接不应该被视为日志文件")
+		}
+	}
+
+	// 软链接路径上已存在普通文件时不覆盖
+	regularDir := t.TempDir()
+	if err := os.WriteFile(path.Join(regularDir, "zcgolog.log"), []byte("普通文件"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateLogFileSymlink(&Config{LogFileDir: regularDir, LogFileNamePrefix: "zcgolog", LogFileExt: ".log"}, "zcgolog_20220507_00001.log"); err == nil {
+		t.Fatal("软链接路径上已存在普通文件时应该返回错误")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	fmt.Println("----- TestReopen -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:   true,
+		LogFileDir:        logDir,
+		LogMod:            LOG_MODE_SERVER,
+		LogFileSymlink:    true,
+		LogReopenOnSIGHUP: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("移走之前的日志")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟logrotate移走当前日志文件
+	rotatedPath := path.Join(logDir, "rotated.1")
+	if err := os.Rename(path.Join(logDir, logger.fileWriter.fileName()), rotatedPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("Reopen之后的日志")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	rotated, _ := os.ReadFile(rotatedPath)
+	current, _ := os.ReadFile(path.Join(logDir, "zcgolog.log"))
+	if !strings.Contains(string(rotated), "移走之前的日志") || strings.Contains(string(rotated), "Reopen之后的日志") ||
+		!strings.Contains(string(current), "Reopen之后的日志") {
+		t.Fatal("Reopen之后的日志应该写入新的日志文件")
+	}
+
+	// 收到SIGHUP信号时重新打开日志文件
+	if err := os.Rename(path.Join(logDir, logger.fileWriter.fileName()), path.Join(logDir, "rotated.2")); err != nil {
+		t.Fatal(err)
+	}
+	logger.loggerLock.Lock()
+	logger.sighupChn <- syscall.SIGHUP
+	logger.loggerLock.Unlock()
+	for i := 0; i < 20; i++ {
+		if _, err := os.Stat(path.Join(logDir, logger.fileWriter.fileName())); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	logger.Info("SIGHUP之后的日志")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logger.sighupChn != nil {
+		t.Fatal("Shutdown之后应该停止SIGHUP信号监听")
+	}
+	current, _ = os.ReadFile(path.Join(logDir, "zcgolog.log"))
+	if !strings.Contains(string(current), "SIGHUP之后的日志") {
+		t.Fatal("收到SIGHUP信号后应该重新打开日志文件")
+	}
+	// Shutdown之后Reopen不做处理
+	if err := logger.Reopen(); err != nil || logger.fileWriter.isOpen() {
+		t.Fatal("Shutdown之后Reopen不应该重新打开日志文件")
+	}
+}
@@ -0,0 +1,165 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_retention.go 历史日志文件的保留策略与清理
+*/
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// 日志目录下的一个日志文件
+type logFileInfo struct {
+	// 文件名
+	name string
+	// 文件大小
+	size int64
+	// 最后修改时间
+	modTime time.Time
+}
+
+// 是否配置了历史日志文件的保留策略
+func (c *Config) retentionEnabled() bool {
+	return c.LogFileMaxAgeDays > 0 || c.LogFileMaxCount > 0 || c.LogFileMaxTotalSizeM > 0
+}
+
+// 列出日志目录下符合日志文件命名约定的文件，按最后修改时间从旧到新排序
+//
+//	日志目录下不符合命名约定的文件不在结果中。
+func listLogFiles(logConfig *Config) ([]logFileInfo, error) {
+	entries, err := os.ReadDir(logConfig.LogFileDir)
+	if err != nil {
+		return nil, err
+	}
+	namer, err := parseLogFileNamePattern(logConfig)
+	if err != nil {
+		return nil, err
+	}
+	nameRegexp := namer.regexp("")
+	var logFiles []logFileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !nameRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// 文件可能已被删除
+			continue
+		}
+		logFiles = append(logFiles, logFileInfo{
+			name:    entry.Name(),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.SliceStable(logFiles, func(i, j int) bool {
+		if logFiles[i].modTime.Equal(logFiles[j].modTime) {
+			return logFiles[i].name < logFiles[j].name
+		}
+		return logFiles[i].modTime.Before(logFiles[j].modTime)
+	})
+	return logFiles, nil
+}
+
+// 选出按保留策略需要删除的日志文件
+//
+//	logFiles需要按从旧到新排序，currentName是当前正在写入的日志文件名，不会被选中。
+//	依次按最长保留天数、最多保留文件数量、最大总大小检查，超出时从最旧的文件开始删除。
+func selectExpiredLogFiles(logConfig *Config, logFiles []logFileInfo, currentName string, now time.Time) []string {
+	var expired []string
+	var kept []logFileInfo
+	var totalSize int64
+	for _, f := range logFiles {
+		if f.name != currentName && logConfig.LogFileMaxAgeDays > 0 &&
+			now.Sub(f.modTime) > time.Duration(logConfig.LogFileMaxAgeDays)*24*time.Hour {
+			expired = append(expired, f.name)
+			continue
+		}
+		kept = append(kept, f)
+		totalSize += f.size
+	}
+	maxTotalSize := int64(logConfig.LogFileMaxTotalSizeM) * 1024 * 1024
+	count := len(kept)
+	for _, f := range kept {
+		overCount := logConfig.LogFileMaxCount > 0 && count > logConfig.LogFileMaxCount
+		overSize := maxTotalSize > 0 && totalSize > maxTotalSize
+		if !overCount && !overSize {
+			break
+		}
+		if f.name == currentName {
+			continue
+		}
+		expired = append(expired, f.name)
+		count--
+		totalSize -= f.size
+	}
+	return expired
+}
+
+// 按保留策略清理历史日志文件
+//
+//	只处理日志目录下符合日志文件命名约定的文件，不会删除当前正在写入的日志文件。
+func cleanLogFiles(logConfig *Config, currentName string) error {
+	logFiles, err := listLogFiles(logConfig)
+	if err != nil {
+		return err
+	}
+	for _, name := range selectExpiredLogFiles(logConfig, logFiles, currentName, time.Now()) {
+		if err := os.Remove(path.Join(logConfig.LogFileDir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// 异步执行历史日志文件压缩与清理，不阻塞日志输出
+//
+//	先压缩待压缩列表中的日志文件，再按保留策略清理，压缩与清理在同一个goroutine中串行执行，
+//	避免清理时删除正在压缩的日志文件。
+//	已有清理任务在执行时，由该任务在结束前再执行一次压缩与清理。
+//	需要在持有lock时调用。
+func (w *logFileWriter) startLogFileJanitor() {
+	if (!w.config.retentionEnabled() && w.config.LogFileCompression == "") || w.config.LogFileDir == "" {
+		return
+	}
+	w.janitorPending.Store(true)
+	if !w.janitorRunning.CompareAndSwap(false, true) {
+		return
+	}
+	logConfig := *w.config
+	w.bgTasks.Add(1)
+	go func() {
+		defer w.bgTasks.Done()
+		for {
+			for w.janitorPending.Swap(false) {
+				w.compressQueuedLogFiles(&logConfig)
+				if !logConfig.retentionEnabled() {
+					continue
+				}
+				// Shutdown关闭日志文件后清理任务可能仍在执行，此时同样不能删除最后的日志文件
+				if err := cleanLogFiles(&logConfig, w.lastOpenedFileName()); err != nil {
+					fmt.Printf("zclog 清理历史日志文件发生错误: %s\n", err)
+				}
+			}
+			w.janitorRunning.Store(false)
+			// 防止在设置为停止前有新的清理请求被跳过
+			if !w.janitorPending.Load() || !w.janitorRunning.CompareAndSwap(false, true) {
+				return
+			}
+		}
+	}()
+}
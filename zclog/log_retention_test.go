@@ -0,0 +1,110 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectExpiredLogFiles(t *testing.T) {
+	fmt.Println("----- TestSelectExpiredLogFiles -----")
+	now := time.Date(2022, 5, 10, 12, 0, 0, 0, time.Local)
+	logFiles := []logFileInfo{
+		{name: "zcgolog_20220501_00001.log", size: 1024 * 1024, modTime: now.AddDate(0, 0, -9)},
+		{name: "zcgolog_20220507_00001.log", size: 1024 * 1024, modTime: now.AddDate(0, 0, -3)},
+		{name: "zcgolog_20220508_00001.log", size: 1024 * 1024, modTime: now.AddDate(0, 0, -2)},
+		{name: "zcgolog_20220509_00001.log", size: 1024 * 1024, modTime: now.AddDate(0, 0, -1)},
+		{name: "zcgolog_20220510_00001.log", size: 1024 * 1024, modTime: now},
+	}
+	current := "zcgolog_20220510_00001.log"
+	tests := []struct {
+		config *Config
+		want   string
+	}{
+		{&Config{}, ""},
+		{&Config{LogFileMaxAgeDays: 7}, "zcgolog_20220501_00001.log"},
+		{&Config{LogFileMaxCount: 3}, "zcgolog_20220501_00001.log,zcgolog_20220507_00001.log"},
+		{&Config{LogFileMaxTotalSizeM: 2}, "zcgolog_20220501_00001.log,zcgolog_20220507_00001.log,zcgolog_20220508_00001.log"},
+		{&Config{LogFileMaxAgeDays: 7, LogFileMaxCount: 2}, "zcgolog_20220501_00001.log,zcgolog_20220507_00001.log,zcgolog_20220508_00001.log"},
+		// 当前日志文件不会被删除
+		{&Config{LogFileMaxCount: 1, LogFileMaxTotalSizeM: 1}, "zcgolog_20220501_00001.log,zcgolog_20220507_00001.log,zcgolog_20220508_00001.log,zcgolog_20220509_00001.log"},
+	}
+	for _, tt := range tests {
+		expired := strings.Join(selectExpiredLogFiles(tt.config, logFiles, current, now), ",")
+		if expired != tt.want {
+			t.Fatalf("需要删除的日志文件不符合预期, config: %+v, 实际: %s, 预期: %s", *tt.config, expired, tt.want)
+		}
+	}
+}
+
+func TestCleanLogFilesOnScroll(t *testing.T) {
+	fmt.Println("----- TestCleanLogFilesOnScroll -----")
+	logDir := t.TempDir()
+	// 不符合日志文件命名约定的文件不会被清理
+	unrelated := []string{"zcgolog_20220501_00001.log.bak", "other_20220501_00001.log", "zcgolog_readme.log"}
+	for _, name := range unrelated {
+		if err := os.WriteFile(path.Join(logDir, name), []byte("unrelated"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := path.Join(logDir, "zcgolog_20220501_00001.log")
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldTime := time.Now().AddDate(0, 0, -30)
+	if err := os.Chtimes(old, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:   true,
+		LogFileDir:        logDir,
+		LogMod:            LOG_MODE_SERVER,
+		LogChnOverPolicy:  LOG_CHN_OVER_POLICY_BLOCK,
+		LogFileMaxSizeM:   1,
+		LogFileMaxAgeDays: 7,
+		LogFileMaxCount:   2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("测试日志", 64)
+	for i := 0; i < 20000; i++ {
+		logger.Info(msg)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	logFiles, err := listLogFiles(logger.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logFiles) != 2 {
+		t.Fatalf("按保留策略清理后应该保留2个日志文件: %v", logFiles)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatal("超过最长保留天数的日志文件应该被删除")
+	}
+	for _, name := range unrelated {
+		if _, err := os.Stat(path.Join(logDir, name)); err != nil {
+			t.Fatalf("不符合日志文件命名约定的文件不应该被清理: %s", name)
+		}
+	}
+}
@@ -15,10 +15,10 @@ zclog/log_server.go zcgolog服务器模式相关代码
 */
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"os"
-	"sync"
 	"time"
 )
 
@@ -48,62 +48,172 @@ type logMsg struct {
 	callFunc string
 	// 日志内容
 	logMsg string
+	// 日志内容是否是格式化字符串，Debugf等格式化输出接口为true
+	formatted bool
 	// 日志内容参数
 	logParams []interface{}
+	// 调用方goroutine ID，仅在日志行格式使用%goid时获取
+	goid uint64
+	// 结构化日志字段
+	fields []Field
+	// 非nil时表示该消息是Sync请求的标记，日志缓冲通道监听处理到该标记时将日志文件落盘并通过该通道返回结果
+	syncDone chan error
 }
 
-// 日志缓冲通道
-var logMsgChn chan logMsg
-
-// 退出通道,用于监听是否需要退出对日志缓冲通道的监听。
-// 服务器模式下刷新日志配置重启服务器模式前，需要先通过该通道，通知当前对日志缓冲通道的监听服务退出。
-var quitChn = make(chan int)
+// 追加日志内容，格式化输出的日志内容按格式化字符串处理
+func (msg *logMsg) appendMessage(buf []byte) []byte {
+	if !msg.formatted {
+		return append(buf, msg.logMsg...)
+	}
+	return fmt.Appendf(buf, msg.logMsg, msg.logParams...)
+}
 
 // 启动zcgolog服务器模式
-func startZcgologServer() {
+func (l *Logger) startZcgologServer() {
 	// 初始化zcgologger
-	initZcgoLogger()
+	l.initZcgoLogger()
 	// 启动日志缓冲通道监听
-	go readAndWriteMsg()
+	go l.readAndWriteMsg()
 	// 等待日志级别控制监听服务启动，
 	// 防止runLogCtlServe执行时日志缓冲通道尚未初始化。
-	_ = waitMsgReaderStart(3000)
-	// 启动日志级别控制监听服务
-	runLogCtlServeOnce.Do(startLogCtlServe)
+	_ = l.waitMsgReaderStart(3000)
+	// 启动日志级别控制监听服务，只有默认Logger会启动
+	if l.isDefault {
+		startLogCtlServe()
+	}
 }
 
-// QuitMsgReader 停止对缓冲消息通道的监听
+// QuitMsgReader 停止默认Logger对缓冲消息通道的监听
 //
 //	timeoutMilliSec 超时时间(毫秒),该值<=0时表示会一直等待直到监听停止。
 func QuitMsgReader(timeoutMilliSec int) error {
-	if !msgReaderRunning {
+	return defaultLogger.QuitMsgReader(timeoutMilliSec)
+}
+
+// QuitMsgReader 停止对缓冲消息通道的监听
+//
+//	timeoutMilliSec 超时时间(毫秒),该值<=0时表示会一直等待直到监听停止。
+//	监听停止前会先输出日志缓冲通道中剩余的日志，并将日志文件落盘后关闭。
+func (l *Logger) QuitMsgReader(timeoutMilliSec int) error {
+	if !l.msgReaderRunning.Load() {
 		return nil
 	}
-	// 请求停止对日志缓冲通道的监听
-	quitChn <- 1
-	startTime := time.Now()
-	// 自旋等待日志缓冲通道监听停止
-	for {
-		time.Sleep(time.Millisecond * 500)
-		if !msgReaderRunning {
-			return nil
-		}
-		if timeoutMilliSec > 0 {
-			timeNow := time.Now()
-			if timeoutMilliSec < int(timeNow.Sub(startTime).Milliseconds()) {
-				return fmt.Errorf("日志缓冲通道监听未能在超时时间内停止, 超时时间(毫秒): %d", timeoutMilliSec)
+	ctx := context.Background()
+	if timeoutMilliSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutMilliSec)*time.Millisecond)
+		defer cancel()
+	}
+	if err := l.stopMsgReader(ctx); err != nil {
+		return fmt.Errorf("日志缓冲通道监听未能在超时时间内停止, 超时时间(毫秒): %d", timeoutMilliSec)
+	}
+	return nil
+}
+
+// 请求停止对日志缓冲通道的监听，并等待监听停止
+func (l *Logger) stopMsgReader(ctx context.Context) error {
+	if !l.msgReaderRunning.Load() {
+		return nil
+	}
+	stopped := l.msgReaderStopped
+	select {
+	case l.quitChn <- 1:
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sync 将默认Logger在调用之前输出的日志全部写入日志文件并落盘
+func Sync() error {
+	return defaultLogger.Sync()
+}
+
+// Sync 将调用之前输出的日志全部写入日志文件并落盘
+//
+//	服务器模式下，会阻塞直到日志缓冲通道中在调用之前推送的日志全部输出，
+//	Sync请求不受LogChnOverPolicy影响，日志缓冲通道已满时会等待而不会被丢弃。
+func (l *Logger) Sync() error {
+	if l.config.LogMod == LOG_MODE_SERVER && l.msgReaderRunning.Load() {
+		stopped := l.msgReaderStopped
+		syncDone := make(chan error, 1)
+		select {
+		case l.logMsgChn <- logMsg{syncDone: syncDone}:
+			select {
+			case err := <-syncDone:
+				return err
+			case <-stopped:
+				// 监听已停止，停止前已输出剩余日志
 			}
+		case <-stopped:
+		}
+	}
+	return l.syncCurrentLogFile()
+}
+
+// Shutdown 关闭默认Logger，并停止日志级别控制监听服务
+func Shutdown(ctx context.Context) error {
+	return defaultLogger.Shutdown(ctx)
+}
+
+// Shutdown 关闭Logger
+//
+//	服务器模式下，停止接收新的日志消息，输出日志缓冲通道中剩余的日志后停止监听；
+//	将日志文件落盘后关闭，之后输出的日志直接输出到控制台；
+//	停止SIGHUP信号监听，等待日志文件滚动后启动的后台任务(如历史日志文件清理)结束；
+//	默认Logger还会停止日志级别控制监听服务。
+//	ctx到期时返回错误，此时日志缓冲通道中可能还有未输出的日志。
+func (l *Logger) Shutdown(ctx context.Context) error {
+	var errs []error
+	if err := l.stopMsgReader(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("日志缓冲通道监听未能在期限内停止: %w", err))
+	} else {
+		// 本地模式或监听已停止时，在这里关闭日志文件
+		if err := l.releaseCurrentLogFile(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.stopSighupHandler()
+	if err := l.waitBgTasks(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("日志文件后台任务未能在期限内结束: %w", err))
+	}
+	if l.isDefault {
+		if err := stopLogCtlServe(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("日志级别控制监听服务未能正常停止: %w", err))
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// 等待日志文件滚动后启动的后台任务结束
+func (l *Logger) waitBgTasks(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		l.fileWriter.bgTasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // 等待日志缓冲通道监听启动
 //
 //	timeoutMilliSec 超时时间(毫秒),该值<=0时表示会一直等待直到监听启动。
-func waitMsgReaderStart(timeoutMilliSec int) error {
+func (l *Logger) waitMsgReaderStart(timeoutMilliSec int) error {
 	startTime := time.Now()
 	for {
-		if msgReaderRunning {
+		if l.msgReaderRunning.Load() {
 			return nil
 		}
 		if timeoutMilliSec > 0 {
@@ -116,94 +226,98 @@ func waitMsgReaderStart(timeoutMilliSec int) error {
 	}
 }
 
-var msgReaderLock sync.Mutex
-var msgReaderRunning = false
-
 // 从日志缓冲通道拉取并输出日志
-func readAndWriteMsg() {
+func (l *Logger) readAndWriteMsg() {
 	// 通过排他锁控制同时只能有一个Goroutine执行该函数
-	msgReaderLock.Lock()
-	defer msgReaderLock.Unlock()
+	l.msgReaderLock.Lock()
+	defer l.msgReaderLock.Unlock()
 	// 初始化日志缓冲通道
-	logMsgChn = make(chan logMsg, zcgologConfig.LogChannelCap)
-	Info("readAndWriteMsg开始")
-	msgReaderRunning = true
-	defer closeCurrentLogFile()
+	l.logMsgChn = make(chan logMsg, l.config.LogChannelCap)
+	l.msgReaderStopped = make(chan struct{})
+	l.Info("readAndWriteMsg开始")
+	l.msgReaderRunning.Store(true)
+	defer close(l.msgReaderStopped)
+	// 定时将日志文件写入缓冲区中的日志写入日志文件，防止日志持续输出时长时间停留在缓冲区
+	flushTicker := time.NewTicker(time.Duration(l.config.LogFileFlushIntervalMilliSec) * time.Millisecond)
+	defer flushTicker.Stop()
 	for {
 		// select IO多路复用 监听日志缓冲通道和退出通道
 		select {
-		case <-quitChn:
-			// 接收到退出指令
-			msgReaderRunning = false
-			zcgoLogger.Println("readAndWriteMsg结束")
+		case <-l.quitChn:
+			// 接收到退出指令，之后的日志不再推送到日志缓冲通道
+			l.msgReaderRunning.Store(false)
+			// 输出日志缓冲通道中剩余的日志
+			for drained := false; !drained; {
+				select {
+				case msg := <-l.logMsgChn:
+					l.writeMsg(&msg)
+				default:
+					drained = true
+				}
+			}
+			l.stdLogger.Println("readAndWriteMsg结束")
+			_ = l.releaseCurrentLogFile()
 			return
-		case msg := <-logMsgChn:
+		case msg := <-l.logMsgChn:
 			// 接收到日志消息
-			// 检查日志文件是否需要滚动
-			if currentLogFile != nil {
-				curLogFileStat, _ := currentLogFile.Stat()
-				todayYMD := getYMDToday()
-				// 当天日期发生变化或当前日志文件大小超过上限时，做日志文件滚动处理
-				if todayYMD != currentLogYMD || curLogFileStat.Size() >= int64(zcgologConfig.LogFileMaxSizeM)*1024*1024 {
-					scrollLogFile()
-				}
+			l.writeMsg(&msg)
+			// 日志缓冲通道已空时，将缓冲区中的日志批量写入日志文件
+			if len(l.logMsgChn) == 0 {
+				l.flushLogFile()
 			}
-			msgPrefix := fmt.Sprintf("%s 时间:%s 代码:%s %d 函数:%s ", LogLevels[msg.logLevel], msg.pushTime.Format(LOG_TIME_FORMAT_YMDHMS), msg.callFile, msg.callLine, msg.callFunc)
-			zcgoLogger.Printf(msgPrefix+msg.logMsg, msg.logParams...)
+		case <-flushTicker.C:
+			l.flushLogFile()
 		}
 	}
 }
 
-// 日志文件滚动处理
-func scrollLogFile() {
-	// 上锁,确保logger操作的线程安全
-	loggerLock.Lock()
-	defer loggerLock.Unlock()
-	// 临时切换zcgoLogger输出到控制台
-	zcgoLogger.SetOutput(os.Stdout)
-	closeCurrentLogFile()
-	logFilePath, ymd, err := GetLogFilePathAndYMDToday(zcgologConfig)
-	if err != nil {
-		// 获取最新日志文件失败时，直接向控制台输出
-		currentLogYMD = getYMDToday()
-		currentLogFile = nil
-		zcgoLogger.Printf("zclog/log.go readAndWriteMsg->GetLogFilePathAndYMDToday 发生错误: %s", err)
-		return
-	}
-	currentLogYMD = ymd
-	currentLogFile, err = os.OpenFile(logFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
-	if err != nil {
-		// 获取最新日志文件失败时，直接向控制台输出
-		currentLogFile = nil
-		zcgoLogger.Printf("zclog/log.go readAndWriteMsg->os.OpenFile 发生错误: %s", err)
+// 输出从日志缓冲通道拉取的日志消息
+func (l *Logger) writeMsg(msg *logMsg) {
+	if msg.syncDone != nil {
+		// Sync请求标记，之前的日志均已输出，将日志文件落盘
+		msg.syncDone <- l.syncCurrentLogFile()
 		return
 	}
-	// 重新设置log输出目标
-	if !zcgologConfig.LogForbidStdout {
-		// 日志同时输出到日志文件与控制台
-		multiWriter := io.MultiWriter(os.Stdout, currentLogFile)
-		zcgoLogger.SetOutput(multiWriter)
-	} else {
-		// 日志只输出到日志文件
-		zcgoLogger.SetOutput(currentLogFile)
+	l.outputMsg(msg)
+}
+
+// 将日志文件与日志输出目标写入缓冲区中的日志批量写入
+func (l *Logger) flushLogFile() {
+	if err := errors.Join(l.fileWriter.Flush(), l.flushSinks(), l.flushErrorFile()); err != nil {
+		fmt.Printf("zclog 日志写入日志文件发生错误: %s\n", err)
 	}
 }
 
+// 将当前日志文件与日志输出目标落盘
+func (l *Logger) syncCurrentLogFile() error {
+	return errors.Join(l.fileWriter.Sync(), l.syncSinks(), l.syncErrorFile())
+}
+
+// 将当前日志文件落盘后关闭，并关闭日志输出目标与错误日志文件，之后的日志直接输出到控制台
+func (l *Logger) releaseCurrentLogFile() error {
+	l.loggerLock.Lock()
+	defer l.loggerLock.Unlock()
+	// 先切换输出目标，确保之后不会再写入已关闭的日志文件
+	l.stdLogger.SetOutput(os.Stdout)
+	sinksErr := l.closeSinks()
+	return errors.Join(l.fileWriter.Close(), sinksErr, l.closeErrorFile())
+}
+
 // 将日志消息推送到日志缓冲通道
-func pushMsgToLogMsgChn(pushMsg logMsg) {
+func (l *Logger) pushMsgToLogMsgChn(pushMsg logMsg) {
 	// 根据LogChnOverPolicy决定是否在缓冲通道已满时阻塞
-	switch zcgologConfig.LogChnOverPolicy {
+	switch l.config.LogChnOverPolicy {
 	case LOG_CHN_OVER_POLICY_BLOCK:
 		// 阻塞模式下，如果缓冲通道已满，则当前goroutine将在此阻塞等待，
 		// 直到下游readAndWriteMsg的goroutine将消息拉走，缓冲通道有空间空出来。
-		logMsgChn <- pushMsg
+		l.logMsgChn <- pushMsg
 	case LOG_CHN_OVER_POLICY_DISCARD:
 		// 丢弃模式下，如果缓冲通道已满，则进入select的default分支，丢弃该条日志，但会直接在控制台输出。
 		select {
-		case logMsgChn <- pushMsg:
+		case l.logMsgChn <- pushMsg:
 			return
 		default:
-			fmt.Printf("日志缓冲通道已满，该条日志被丢弃:"+pushMsg.logMsg+"\n", pushMsg.logParams...)
+			fmt.Printf("日志缓冲通道已满，该条日志被丢弃:%s\n", pushMsg.appendMessage(nil))
 			return
 		}
 		// TODO 考虑是否添加新的策略，比如将日志直接输出到fallback的输出流?
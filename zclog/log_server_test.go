@@ -0,0 +1,98 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncAndShutdown(t *testing.T) {
+	fmt.Println("----- TestSyncAndShutdown -----")
+	logDir := t.TempDir()
+	logger, err := NewLogger(&Config{
+		LogForbidStdout:  true,
+		LogFileDir:       logDir,
+		LogMod:           LOG_MODE_SERVER,
+		LogChnOverPolicy: LOG_CHN_OVER_POLICY_BLOCK,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		logger.Infof("Sync之前的日志: %d", i+1)
+	}
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	// Sync返回时，之前推送的日志应该已经全部写入日志文件
+	content := readSingleLogFile(t, logDir, "zcgolog_")
+	if strings.Count(content, "Sync之前的日志") != 1000 {
+		t.Fatalf("Sync之后日志文件中的日志条数不符合预期: %d", strings.Count(content, "Sync之前的日志"))
+	}
+
+	for i := 0; i < 1000; i++ {
+		logger.Infof("Shutdown之前的日志: %d", i+1)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if logger.msgReaderRunning.Load() || logger.fileWriter.isOpen() {
+		t.Fatal("Shutdown之后日志缓冲通道监听应该停止，日志文件应该关闭")
+	}
+	// Shutdown之后输出的日志直接输出到控制台
+	logger.Info("Shutdown之后的日志")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	content = readSingleLogFile(t, logDir, "zcgolog_")
+	if strings.Count(content, "Shutdown之前的日志") != 1000 || strings.Contains(content, "Shutdown之后的日志") {
+		t.Fatal("Shutdown之前推送的日志应该全部写入日志文件")
+	}
+	if err := logger.Shutdown(ctx); err != nil {
+		t.Fatal("重复Shutdown不应返回错误:", err)
+	}
+}
+
+func TestShutdownLogCtlServer(t *testing.T) {
+	fmt.Println("----- TestShutdownLogCtlServer -----")
+	InitLogger(&Config{
+		LogFileDir:      t.TempDir(),
+		LogMod:          LOG_MODE_SERVER,
+		LogLevelCtlPort: "19301",
+	})
+	queryURL := "http://localhost:19301" + log_ctl_uri_level_query
+	var resp *http.Response
+	var err error
+	for i := 0; i < 20; i++ {
+		if resp, err = http.Get(queryURL); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	Info("Shutdown之前的日志")
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get(queryURL); err == nil {
+		t.Fatal("Shutdown之后日志级别控制监听服务应该停止")
+	}
+}
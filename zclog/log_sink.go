@@ -0,0 +1,519 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_sink.go 日志输出目标(Sink)，支持同时输出到多个目标，每个目标可以指定自己的最低日志级别与编码
+*/
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 日志输出目标类型定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_SINK_CONSOLE 输出到控制台
+	LOG_SINK_CONSOLE = iota + 1
+	// LOG_SINK_FILE 输出到日志文件，日志目录、命名、滚动与保留策略沿用Logger的配置
+	LOG_SINK_FILE
+	// LOG_SINK_SYSLOG 输出到syslog服务，参考SyslogConfig
+	LOG_SINK_SYSLOG
+	// LOG_SINK_NET 通过TCP(可选TLS)输出到日志收集服务，参考NetSinkConfig
+	LOG_SINK_NET
+	// LOG_SINK_HTTP 分批POST到日志接收服务，参考HTTPSinkConfig
+	LOG_SINK_HTTP
+	// log_sink_max 日志输出目标类型定义值上限
+	log_sink_max
+)
+
+// Sink 日志输出目标
+//
+//	服务器模式下由日志缓冲通道监听goroutine串行调用；
+//	本地模式下由输出日志的goroutine直接调用，因此实现需要是并发安全的。
+type Sink interface {
+	// Write 输出一条日志
+	Write(entry *Entry) error
+	// Sync 将已输出的日志落盘
+	Sync() error
+	// Close 关闭输出目标，Logger关闭(Shutdown或QuitMsgReader)时调用
+	Close() error
+}
+
+// Entry 输出到Sink的日志条目
+type Entry struct {
+	// 调用方请求输出日志的时间
+	Time time.Time
+	// 日志级别
+	Level int
+	// 日志位置-代码文件
+	File string
+	// 日志位置-代码文件行数
+	Line int
+	// 日志位置-调用函数
+	Func string
+	// 日志内容，已按日志内容参数格式化
+	Message string
+	// 结构化日志字段
+	Fields []Field
+	// 原始日志消息，Entry未被修改时内置输出目标直接使用，调用方自行构造Entry时为nil
+	msg *logMsg
+	// 生成Entry时的日志内容，用于判断Message是否被修改
+	message string
+}
+
+// 根据日志消息生成日志条目
+func newEntry(msg *logMsg) *Entry {
+	message := string(msg.appendMessage(nil))
+	return &Entry{
+		Time:    msg.pushTime,
+		Level:   msg.logLevel,
+		File:    msg.callFile,
+		Line:    msg.callLine,
+		Func:    msg.callFunc,
+		Message: message,
+		Fields:  msg.fields,
+		msg:     msg,
+		message: message,
+	}
+}
+
+// 获取内置输出目标编码使用的日志消息
+//
+//	Entry由zclog生成且未被修改时直接使用原始日志消息；
+//	Entry由调用方自行构造，或导出字段被修改(如包装其他Sink的自定义Sink修改了日志内容)时，按导出字段重新生成日志消息。
+func (e *Entry) encodeMsg() *logMsg {
+	if e.msg != nil && e.unchanged() {
+		return e.msg
+	}
+	msg := &logMsg{
+		pushTime: e.Time,
+		logLevel: e.Level,
+		callFile: e.File,
+		callLine: e.Line,
+		callFunc: e.Func,
+		logMsg:   e.Message,
+		fields:   e.Fields,
+	}
+	if e.msg != nil {
+		msg.goid = e.msg.goid
+	}
+	return msg
+}
+
+// Entry的导出字段与原始日志消息是否一致
+func (e *Entry) unchanged() bool {
+	msg := e.msg
+	return e.Time.Equal(msg.pushTime) && e.Level == msg.logLevel && e.File == msg.callFile &&
+		e.Line == msg.callLine && e.Func == msg.callFunc && e.Message == e.message &&
+		len(e.Fields) == len(msg.fields) && (len(e.Fields) == 0 || &e.Fields[0] == &msg.fields[0])
+}
+
+// SinkConfig 日志输出目标配置
+type SinkConfig struct {
+	// 输出目标类型: LOG_SINK_CONSOLE 控制台; LOG_SINK_FILE 日志文件; LOG_SINK_SYSLOG syslog服务; LOG_SINK_NET 日志收集服务; LOG_SINK_HTTP HTTP日志接收服务。Sink非nil时忽略该配置
+	Type int `json:"type" yaml:"type" mapstructure:"type"`
+	// 最低日志级别，低于该级别的日志不输出到该目标，默认: LOG_LEVEL_DEBUG
+	Level int `json:"level" yaml:"level" mapstructure:"level"`
+	// 日志输出编码，默认沿用LogEncoder；LOG_SINK_NET与LOG_SINK_HTTP固定使用JSON编码
+	Encoder int `json:"encoder" yaml:"encoder" mapstructure:"encoder"`
+	// 日志行格式，默认沿用LogLineFormat；LOG_SINK_SYSLOG默认为"%msg"，时间、级别等由syslog消息头表示
+	LineFormat string `json:"line_format" yaml:"line_format" mapstructure:"line_format"`
+	// 日志文件名前缀，仅LOG_SINK_FILE使用，默认沿用LogFileNamePrefix
+	FileNamePrefix string `json:"file_name_prefix" yaml:"file_name_prefix" mapstructure:"file_name_prefix"`
+	// syslog输出目标配置，仅LOG_SINK_SYSLOG使用
+	Syslog *SyslogConfig `json:"syslog" yaml:"syslog" mapstructure:"syslog"`
+	// 网络输出目标配置，仅LOG_SINK_NET使用
+	Net *NetSinkConfig `json:"net" yaml:"net" mapstructure:"net"`
+	// HTTP输出目标配置，仅LOG_SINK_HTTP使用
+	HTTP *HTTPSinkConfig `json:"http" yaml:"http" mapstructure:"http"`
+	// 自定义输出目标
+	Sink Sink `json:"-" yaml:"-" mapstructure:"-"`
+}
+
+// 日志输出目标及其最低日志级别
+type logSink struct {
+	sink  Sink
+	level int
+}
+
+// 支持将缓冲区中的日志批量写入的Sink，日志缓冲通道已空或到达刷新间隔时调用
+type sinkFlusher interface {
+	Flush() error
+}
+
+// 检查日志输出目标配置
+func checkSinkConfigs(logConfig *Config) error {
+	filePrefixes := map[string]bool{}
+	spoolFiles := map[string]bool{}
+	if logConfig.LogErrorFileLevel > 0 {
+		filePrefixes[errorFileNamePrefix(logConfig)] = true
+	}
+	for i, sc := range logConfig.LogSinks {
+		if sc.Level != 0 && (sc.Level < LOG_LEVEL_DEBUG || sc.Level >= log_level_max) {
+			return fmt.Errorf("第%d个日志输出目标的日志级别不能超出有效范围", i+1)
+		}
+		if sc.Encoder != 0 && (sc.Encoder < LOG_ENCODER_TEXT || sc.Encoder >= log_encoder_max) {
+			return fmt.Errorf("第%d个日志输出目标的日志输出编码不能超出有效范围", i+1)
+		}
+		if sc.LineFormat != "" {
+			if err := CheckLogLineFormat(sc.LineFormat); err != nil {
+				return fmt.Errorf("第%d个日志输出目标的日志行格式不合法: %w", i+1, err)
+			}
+		}
+		if sc.Sink != nil {
+			continue
+		}
+		switch sc.Type {
+		case LOG_SINK_CONSOLE:
+		case LOG_SINK_FILE:
+			if logConfig.LogFileDir == "" {
+				return fmt.Errorf("第%d个日志输出目标为日志文件，日志目录不可为空", i+1)
+			}
+			prefix := sc.FileNamePrefix
+			if prefix == "" {
+				prefix = logConfig.LogFileNamePrefix
+			}
+			if filePrefixes[prefix] {
+				return fmt.Errorf("多个日志文件输出目标不能使用相同的日志文件名前缀: %s", prefix)
+			}
+			filePrefixes[prefix] = true
+		case LOG_SINK_SYSLOG:
+			if err := checkSyslogConfig(sc.Syslog); err != nil {
+				return fmt.Errorf("第%d个日志输出目标的syslog配置不合法: %w", i+1, err)
+			}
+		case LOG_SINK_NET:
+			if err := checkNetSinkConfig(sc.Net); err != nil {
+				return fmt.Errorf("第%d个日志输出目标的网络输出配置不合法: %w", i+1, err)
+			}
+			spoolFile := sc.Net.SpoolFile
+			if spoolFile == "" {
+				if logConfig.LogFileDir == "" {
+					return fmt.Errorf("第%d个日志输出目标为日志收集服务，未配置本地缓存文件时日志目录不可为空", i+1)
+				}
+				spoolFile = defaultNetSpoolFile(logConfig)
+			}
+			if spoolFiles[spoolFile] {
+				return fmt.Errorf("多个输出目标不能使用相同的本地缓存文件: %s", spoolFile)
+			}
+			spoolFiles[spoolFile] = true
+		case LOG_SINK_HTTP:
+			if err := checkHTTPSinkConfig(sc.HTTP); err != nil {
+				return fmt.Errorf("第%d个日志输出目标的HTTP输出配置不合法: %w", i+1, err)
+			}
+			if sc.HTTP.OverPolicy != LOG_HTTP_OVER_POLICY_SPOOL {
+				continue
+			}
+			spoolFile := sc.HTTP.SpoolFile
+			if spoolFile == "" {
+				if logConfig.LogFileDir == "" {
+					return fmt.Errorf("第%d个日志输出目标为HTTP日志接收服务，未配置本地缓存文件时日志目录不可为空", i+1)
+				}
+				spoolFile = defaultHTTPSpoolFile(logConfig)
+			}
+			if spoolFiles[spoolFile] {
+				return fmt.Errorf("多个输出目标不能使用相同的本地缓存文件: %s", spoolFile)
+			}
+			spoolFiles[spoolFile] = true
+		default:
+			return fmt.Errorf("第%d个日志输出目标的类型不能超出有效范围", i+1)
+		}
+	}
+	return nil
+}
+
+// 根据日志输出编码与日志行格式创建日志编码器，日志行格式为空时使用默认格式
+func newLogEncoder(encoder int, lineFormat string) (logEncoder, error) {
+	if encoder == LOG_ENCODER_JSON {
+		return jsonEncoder{}, nil
+	}
+	if lineFormat == "" {
+		lineFormat = LOG_LINE_FORMAT_DEFAULT
+	}
+	return parseLineFormat(lineFormat)
+}
+
+// 日志输出目标配置中是否有日志行格式使用了%goid
+func sinkConfigsNeedGoid(logConfig *Config) bool {
+	for _, sc := range logConfig.LogSinks {
+		if sc.Sink != nil || sc.Type == LOG_SINK_NET || sc.Type == LOG_SINK_HTTP || (sc.Encoder == 0 && logConfig.LogEncoder == LOG_ENCODER_JSON) || sc.Encoder == LOG_ENCODER_JSON {
+			continue
+		}
+		lineFormat := sc.LineFormat
+		if lineFormat == "" && sc.Type != LOG_SINK_SYSLOG {
+			lineFormat = logConfig.LogLineFormat
+		}
+		if formatter, err := parseLineFormat(lineFormat); err == nil && formatter.needGoid {
+			return true
+		}
+	}
+	return false
+}
+
+// 输出到io.Writer的Sink
+//
+//	文本编码时与默认输出一致，在日志行前添加写入时间前缀；JSON编码时每条日志输出为一行JSON对象。
+//	不持有io.Writer，Sync与Close不做处理。
+type writerSink struct {
+	lock    sync.Mutex
+	w       io.Writer
+	encoder logEncoder
+	// 是否是文本编码，文本编码时添加写入时间前缀
+	text bool
+	// 编码缓冲区，写入时复用
+	buf []byte
+}
+
+// NewWriterSink 创建输出到io.Writer的Sink
+//
+//	encoder 日志输出编码，为0时采用LOG_ENCODER_TEXT；lineFormat 日志行格式，为空时采用默认格式。
+//	Sync与Close不会同步或关闭w。
+func NewWriterSink(w io.Writer, encoder int, lineFormat string) (Sink, error) {
+	return newWriterSink(w, encoder, lineFormat)
+}
+
+func newWriterSink(w io.Writer, encoder int, lineFormat string) (*writerSink, error) {
+	if encoder == 0 {
+		encoder = LOG_ENCODER_TEXT
+	}
+	if encoder < LOG_ENCODER_TEXT || encoder >= log_encoder_max {
+		return nil, fmt.Errorf("日志输出编码不能超出有效范围: %d", encoder)
+	}
+	logEnc, err := newLogEncoder(encoder, lineFormat)
+	if err != nil {
+		return nil, err
+	}
+	return &writerSink{w: w, encoder: logEnc, text: encoder == LOG_ENCODER_TEXT}, nil
+}
+
+func (s *writerSink) Write(entry *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	buf := s.buf[:0]
+	if s.text {
+		// 与log.Ldate|log.Ltime相同的写入时间前缀
+		buf = time.Now().AppendFormat(buf, "2006/01/02 15:04:05 ")
+	}
+	buf = s.encoder.encode(buf, entry.encodeMsg())
+	if len(buf) == 0 || buf[len(buf)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	s.buf = buf
+	_, err := s.w.Write(buf)
+	return err
+}
+
+func (s *writerSink) Sync() error {
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// 输出到日志文件的Sink，日志文件的滚动、压缩与保留策略与默认的日志文件输出相同
+type fileSink struct {
+	*writerSink
+	writer *logFileWriter
+}
+
+func (s *fileSink) Flush() error {
+	return s.writer.Flush()
+}
+
+func (s *fileSink) Sync() error {
+	return s.writer.Sync()
+}
+
+func (s *fileSink) Close() error {
+	return s.writer.Close()
+}
+
+// 根据配置创建日志输出目标
+func (l *Logger) newSink(sc *SinkConfig) (Sink, error) {
+	if sc.Sink != nil {
+		return sc.Sink, nil
+	}
+	encoder := sc.Encoder
+	if encoder == 0 {
+		encoder = l.config.LogEncoder
+	}
+	lineFormat := sc.LineFormat
+	if lineFormat == "" && sc.Type != LOG_SINK_SYSLOG {
+		lineFormat = l.config.LogLineFormat
+	}
+	switch sc.Type {
+	case LOG_SINK_CONSOLE:
+		return newWriterSink(os.Stdout, encoder, lineFormat)
+	case LOG_SINK_FILE:
+		prefix := sc.FileNamePrefix
+		if prefix == "" {
+			prefix = l.config.LogFileNamePrefix
+		}
+		sink, err := l.newFileSink(prefix, encoder, lineFormat)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case LOG_SINK_SYSLOG:
+		sink, err := newSyslogSink(sc.Syslog, encoder, lineFormat)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case LOG_SINK_NET:
+		netConfig := *sc.Net
+		if netConfig.SpoolFile == "" {
+			netConfig.SpoolFile = defaultNetSpoolFile(l.config)
+		}
+		sink, err := newNetSink(&netConfig, l.config)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case LOG_SINK_HTTP:
+		httpConfig := *sc.HTTP
+		if httpConfig.OverPolicy == LOG_HTTP_OVER_POLICY_SPOOL && httpConfig.SpoolFile == "" {
+			httpConfig.SpoolFile = defaultHTTPSpoolFile(l.config)
+		}
+		sink, err := newHTTPSink(&httpConfig, l.config)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	default:
+		return nil, fmt.Errorf("不支持的日志输出目标类型: %d", sc.Type)
+	}
+}
+
+// 创建输出到日志文件的Sink并打开日志文件
+//
+//	沿用Logger的日志文件配置，只替换日志文件名前缀；后台任务与Logger的日志文件输出共享，Shutdown时一并等待。
+func (l *Logger) newFileSink(prefix string, encoder int, lineFormat string) (*fileSink, error) {
+	fileConfig := *l.config
+	fileConfig.LogSinks = nil
+	fileConfig.LogErrorFileLevel = 0
+	fileConfig.LogFileNamePrefix = prefix
+	writer := newLogFileWriter(&fileConfig)
+	writer.bgTasks = l.fileWriter.bgTasks
+	if _, err := writer.open(); err != nil {
+		return nil, err
+	}
+	ws, err := newWriterSink(writer, encoder, lineFormat)
+	if err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	return &fileSink{writerSink: ws, writer: writer}, nil
+}
+
+// 根据配置打开所有日志输出目标，无法打开的目标在控制台输出错误后跳过
+func (l *Logger) openSinks() {
+	var sinks []*logSink
+	for i := range l.config.LogSinks {
+		sc := &l.config.LogSinks[i]
+		sink, err := l.newSink(sc)
+		if err != nil {
+			l.stdLogger.Printf("zclog 打开第%d个日志输出目标发生错误: %s", i+1, err)
+			continue
+		}
+		level := sc.Level
+		if level == 0 {
+			level = LOG_LEVEL_DEBUG
+		}
+		sinks = append(sinks, &logSink{sink: sink, level: level})
+	}
+	if len(sinks) > 0 {
+		l.sinks.Store(&sinks)
+	}
+}
+
+// 关闭所有日志输出目标，之后的日志由stdLogger输出
+func (l *Logger) closeSinks() error {
+	sinks := l.sinks.Swap(nil)
+	if sinks == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range *sinks {
+		errs = append(errs, s.sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// 将日志消息输出到所有不低于其最低日志级别的日志输出目标
+//
+//	没有打开的日志输出目标时返回false，此时由stdLogger输出。
+func (l *Logger) writeSinks(msg *logMsg) bool {
+	sinks := l.sinks.Load()
+	if sinks == nil {
+		return false
+	}
+	entry := newEntry(msg)
+	for _, s := range *sinks {
+		if msg.logLevel < s.level {
+			continue
+		}
+		if err := s.sink.Write(entry); err != nil {
+			fmt.Printf("zclog 日志输出到日志输出目标发生错误: %s\n", err)
+		}
+	}
+	return true
+}
+
+// 将日志输出目标缓冲区中的日志批量写入
+func (l *Logger) flushSinks() error {
+	sinks := l.sinks.Load()
+	if sinks == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range *sinks {
+		if flusher, ok := s.sink.(sinkFlusher); ok {
+			errs = append(errs, flusher.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 将所有日志输出目标落盘
+func (l *Logger) syncSinks() error {
+	sinks := l.sinks.Load()
+	if sinks == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range *sinks {
+		errs = append(errs, s.sink.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// 重新打开日志文件输出目标的日志文件
+func (l *Logger) reopenSinks() error {
+	sinks := l.sinks.Load()
+	if sinks == nil {
+		return nil
+	}
+	var errs []error
+	for _, s := range *sinks {
+		if fs, ok := s.sink.(*fileSink); ok {
+			errs = append(errs, fs.writer.reopen())
+		}
+	}
+	return errors.Join(errs...)
+}
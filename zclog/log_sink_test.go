@@ -0,0 +1,260 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录日志条目的Sink，测试用
+type recordSink struct {
+	lock    sync.Mutex
+	entries []Entry
+	synced  int
+	closed  bool
+}
+
+func (s *recordSink) Write(entry *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+func (s *recordSink) Sync() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.synced++
+	return nil
+}
+
+func (s *recordSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestCheckSinkConfigs(t *testing.T) {
+	fmt.Println("----- TestCheckSinkConfigs -----")
+	invalidSinks := [][]SinkConfig{
+		{{Type: LOG_SINK_FILE}},
+		{{Type: 0}},
+		{{Type: LOG_SINK_CONSOLE, Level: log_level_max}},
+		{{Type: LOG_SINK_CONSOLE, Encoder: log_encoder_max}},
+		{{Type: LOG_SINK_CONSOLE, LineFormat: "%level"}},
+	}
+	for i, sinks := range invalidSinks {
+		config := newDefaultConfig()
+		config.LogSinks = sinks
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("第%d组日志输出目标配置应该校验失败", i+1)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	config := newDefaultConfig()
+	config.LogFileDir = t.TempDir()
+	config.LogSinks = []SinkConfig{{Type: LOG_SINK_FILE}, {Type: LOG_SINK_FILE, FileNamePrefix: config.LogFileNamePrefix}}
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("多个日志文件输出目标使用相同的日志文件名前缀时应该校验失败")
+	}
+	config.LogSinks[1].FileNamePrefix = "errors"
+	if _, err := CheckConfig(config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSinks(t *testing.T) {
+	fmt.Println("----- TestSinks -----")
+	logDir := t.TempDir()
+	var consoleBuf bytes.Buffer
+	consoleSink, err := NewWriterSink(&consoleBuf, LOG_ENCODER_TEXT, "%level %msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, err := NewLogger(&Config{
+		LogFileDir:     logDir,
+		LogMod:         LOG_MODE_SERVER,
+		LogLevelGlobal: LOG_LEVEL_DEBUG,
+		LogSinks: []SinkConfig{
+			{Sink: consoleSink, Level: LOG_LEVEL_INFO},
+			{Type: LOG_SINK_FILE, Encoder: LOG_ENCODER_JSON},
+			{Type: LOG_SINK_FILE, Level: LOG_LEVEL_ERROR, FileNamePrefix: "errors", LineFormat: "%level %msg"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("调试日志")
+	logger.Infof("普通日志: %d", 1)
+	logger.Errorw("错误日志", "code", 500)
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 控制台目标: INFO及以上，文本编码
+	console := consoleBuf.String()
+	if strings.Contains(console, "调试日志") || !strings.Contains(console, "INFO] 普通日志: 1") ||
+		!strings.Contains(console, "ERROR] 错误日志 code=500") {
+		t.Fatalf("控制台输出目标的日志不符合预期: %s", console)
+	}
+	// 日志文件目标: DEBUG及以上，JSON编码
+	logFiles, err := listLogFiles(logger.config)
+	if err != nil || len(logFiles) != 1 {
+		t.Fatalf("日志文件数量不符合预期: %v, %v", logFiles, err)
+	}
+	content, err := os.ReadFile(path.Join(logDir, logFiles[0].name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("日志文件输出目标应该输出JSON: %s", line)
+		}
+		msgs[entry["msg"].(string)] = true
+	}
+	if !msgs["调试日志"] || !msgs["普通日志: 1"] || !msgs["错误日志"] {
+		t.Fatalf("日志文件输出目标的日志不符合预期: %s", content)
+	}
+	// 错误日志文件目标: 只有ERROR及以上，使用自己的日志文件名前缀
+	errorConfig := *logger.config
+	errorConfig.LogFileNamePrefix = "errors"
+	errorFiles, err := listLogFiles(&errorConfig)
+	if err != nil || len(errorFiles) != 1 {
+		t.Fatalf("错误日志文件数量不符合预期: %v, %v", errorFiles, err)
+	}
+	content, err = os.ReadFile(path.Join(logDir, errorFiles[0].name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "普通日志") || !strings.Contains(string(content), "ERROR] 错误日志 code=500") {
+		t.Fatalf("错误日志文件输出目标的日志不符合预期: %s", content)
+	}
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logger.sinks.Load() != nil {
+		t.Fatal("Shutdown之后应该关闭日志输出目标")
+	}
+}
+
+func TestCustomSink(t *testing.T) {
+	fmt.Println("----- TestCustomSink -----")
+	sink := &recordSink{}
+	logger, err := NewLogger(&Config{
+		LogMod:         LOG_MODE_LOCAL,
+		LogLevelGlobal: LOG_LEVEL_DEBUG,
+		LogSinks:       []SinkConfig{{Sink: sink, Level: LOG_LEVEL_WARNING}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("不输出的日志")
+	logger.With("node", 3).Warnf("节点%s异常", "n3")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.entries) != 1 {
+		t.Fatalf("自定义输出目标的日志条目数量不符合预期: %d", len(sink.entries))
+	}
+	entry := sink.entries[0]
+	if entry.Level != LOG_LEVEL_WARNING || entry.Message != "节点n3异常" || entry.Line == 0 ||
+		!strings.HasSuffix(entry.File, "log_sink_test.go") || len(entry.Fields) != 1 || entry.Fields[0].Key != "node" {
+		t.Fatalf("日志条目内容不符合预期: %+v", entry)
+	}
+	if sink.synced == 0 || !sink.closed {
+		t.Fatal("Sync与Shutdown应该调用自定义输出目标的Sync与Close")
+	}
+}
+
+// 修改日志内容后转发到内置输出目标的Sink，测试用
+type redactSink struct {
+	next Sink
+}
+
+func (s *redactSink) Write(entry *Entry) error {
+	entry.Message = strings.ReplaceAll(entry.Message, "123456", "******")
+	return s.next.Write(entry)
+}
+
+func (s *redactSink) Sync() error {
+	return s.next.Sync()
+}
+
+func (s *redactSink) Close() error {
+	return s.next.Close()
+}
+
+func TestBuiltinSinkWithEntryFields(t *testing.T) {
+	fmt.Println("----- TestBuiltinSinkWithEntryFields -----")
+	var buf bytes.Buffer
+	sink, err := NewWriterSink(&buf, LOG_ENCODER_JSON, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调用方自行构造的Entry按导出字段编码
+	entry := &Entry{
+		Time:    time.Now(),
+		Level:   LOG_LEVEL_WARNING,
+		File:    "main.go",
+		Line:    10,
+		Func:    "main.main",
+		Message: "进度 100%",
+		Fields:  []Field{String("user", "zhangsan")},
+	}
+	if err := sink.Write(entry); err != nil {
+		t.Fatal(err)
+	}
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("自行构造的Entry输出不是合法的JSON: %s, %s", buf.String(), err)
+	}
+	if line["level"] != "warning" || line["msg"] != "进度 100%" || line["file"] != "main.go" ||
+		line["line"] != float64(10) || line["func"] != "main.main" || line["user"] != "zhangsan" {
+		t.Fatalf("自行构造的Entry输出不符合预期: %v", line)
+	}
+
+	// 包装内置输出目标的Sink修改日志内容后，内置输出目标按修改后的内容编码
+	buf.Reset()
+	logger, err := NewLogger(&Config{
+		LogMod:   LOG_MODE_LOCAL,
+		LogSinks: []SinkConfig{{Sink: &redactSink{next: sink}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Infof("用户密码: %s", "123456")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	line = nil
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("修改后的Entry输出不是合法的JSON: %s, %s", buf.String(), err)
+	}
+	if line["msg"] != "用户密码: ******" || !strings.HasSuffix(line["file"].(string), "log_sink_test.go") {
+		t.Fatalf("修改后的Entry输出不符合预期: %v", line)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_spool.go 本地缓存文件，远程输出目标无法送达时按行缓存日志，恢复后按顺序重放
+*/
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// 本地缓存文件的扩展名
+const spoolFileExt = ".spool"
+
+// 本地缓存重放位置文件的扩展名，追加在本地缓存文件路径之后
+const spoolOffsetFileExt = ".offset"
+
+// 远程输出目标的默认本地缓存文件路径: [LogFileDir]/[LogFileNamePrefix]_[name].spool
+func defaultSpoolFile(logConfig *Config, name string) string {
+	return path.Join(logConfig.LogFileDir, logConfig.LogFileNamePrefix+"_"+name+spoolFileExt)
+}
+
+// 本地缓存文件
+//
+//	日志按行追加到文件末尾，重放时从已重放的位置读取完整的日志行，全部重放后清空文件。
+//	已重放的位置保存在 [缓存文件路径].offset 文件中，程序重启后从该位置继续重放，不会重复发送已重放的日志。
+//	不是并发安全的，由使用方加锁或在单个goroutine中使用。
+type logSpool struct {
+	path string
+	file *os.File
+	// 重放位置文件，内容为8字节大端序的已重放位置，没有已重放的日志时为空
+	offsetFile *os.File
+	// 缓存文件的大小与已重放的位置
+	size   int64
+	offset int64
+	// 缓存文件大小上限
+	maxSize int64
+}
+
+// 打开本地缓存文件，已存在的缓存文件(如上次运行时未重放完)会保留，之后从上次的重放位置继续重放
+//
+//	缓存文件、重放位置文件及其所在目录采用logConfig中的日志文件权限与属组。
+func openLogSpool(spoolPath string, maxSizeM int, logConfig *Config) (*logSpool, error) {
+	spoolConfig := *logConfig
+	spoolConfig.LogFileDir = path.Dir(spoolPath)
+	if err := ensureLogFileDir(&spoolConfig); err != nil {
+		return nil, err
+	}
+	file, err := openSpoolFile(spoolPath, os.O_APPEND, logConfig)
+	if err != nil {
+		return nil, fmt.Errorf("打开本地缓存文件发生错误: %w", err)
+	}
+	offsetFile, err := openSpoolFile(spoolPath+spoolOffsetFileExt, 0, logConfig)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("打开本地缓存重放位置文件发生错误: %w", err)
+	}
+	s := &logSpool{path: spoolPath, file: file, offsetFile: offsetFile, maxSize: int64(maxSizeM) * 1024 * 1024}
+	fileStat, err := file.Stat()
+	if err != nil {
+		_ = s.closeFiles()
+		return nil, err
+	}
+	s.size = fileStat.Size()
+	// 重放位置文件不完整或超出缓存文件大小时，从头重放
+	var offsetBuf [8]byte
+	if _, err := offsetFile.ReadAt(offsetBuf[:], 0); err == nil {
+		if offset := int64(binary.BigEndian.Uint64(offsetBuf[:])); offset >= 0 && offset <= s.size {
+			s.offset = offset
+		}
+	}
+	return s, nil
+}
+
+// 打开本地缓存文件或重放位置文件，新建的文件采用logConfig中的日志文件权限与属组
+func openSpoolFile(filePath string, flag int, logConfig *Config) (*os.File, error) {
+	_, statErr := os.Stat(filePath)
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|flag, logConfig.LogFileMode)
+	if err != nil {
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		if err := applyLogFilePerm(logConfig, filePath, logConfig.LogFileMode); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// 是否有尚未重放的日志
+func (s *logSpool) pending() bool {
+	return s.offset < s.size
+}
+
+// 追加日志行，缓存文件已满时不写入并返回false
+func (s *logSpool) append(data []byte) (bool, error) {
+	if s.size+int64(len(data)) > s.maxSize {
+		return false, nil
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return true, fmt.Errorf("日志写入本地缓存文件发生错误: %w", err)
+	}
+	return true, nil
+}
+
+// 从已重放的位置读取若干完整的日志行追加到buf
+//
+//	读取的字节数达到maxBytes或行数达到maxLines(为0时不限制)后停止，至少读取一行；
+//	缓存文件末尾不完整的日志行无法重放，直接跳过。读取后需要调用advance推进重放位置。
+func (s *logSpool) read(buf []byte, maxBytes int, maxLines int) ([]byte, int, error) {
+	r := bufio.NewReader(io.NewSectionReader(s.file, s.offset, s.size-s.offset))
+	start := len(buf)
+	lines := 0
+	for len(buf)-start < maxBytes && (maxLines == 0 || lines < maxLines) {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			buf = append(buf, line...)
+			lines++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return buf[:start], 0, fmt.Errorf("读取本地缓存文件发生错误: %w", err)
+		}
+	}
+	if lines == 0 {
+		s.offset = s.size
+	}
+	return buf, lines, nil
+}
+
+// 推进重放位置并保存到重放位置文件，全部重放后清空缓存文件与重放位置文件
+func (s *logSpool) advance(n int) error {
+	s.offset += int64(n)
+	if s.pending() {
+		var offsetBuf [8]byte
+		binary.BigEndian.PutUint64(offsetBuf[:], uint64(s.offset))
+		if _, err := s.offsetFile.WriteAt(offsetBuf[:], 0); err != nil {
+			return fmt.Errorf("保存本地缓存重放位置发生错误: %w", err)
+		}
+		return nil
+	}
+	if err := s.file.Truncate(0); err != nil {
+		return fmt.Errorf("清空本地缓存文件发生错误: %w", err)
+	}
+	s.size = 0
+	s.offset = 0
+	if err := s.offsetFile.Truncate(0); err != nil {
+		return fmt.Errorf("清空本地缓存重放位置文件发生错误: %w", err)
+	}
+	return nil
+}
+
+// 将缓存文件与重放位置文件落盘
+func (s *logSpool) sync() error {
+	return errors.Join(s.file.Sync(), s.offsetFile.Sync())
+}
+
+// 关闭缓存文件，没有缓存的日志时删除缓存文件与重放位置文件
+func (s *logSpool) close() error {
+	err := s.closeFiles()
+	if s.size == 0 {
+		_ = os.Remove(s.path)
+		_ = os.Remove(s.path + spoolOffsetFileExt)
+	}
+	return err
+}
+
+// 关闭缓存文件与重放位置文件
+func (s *logSpool) closeFiles() error {
+	return errors.Join(s.file.Close(), s.offsetFile.Close())
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestLogSpoolOffset(t *testing.T) {
+	fmt.Println("----- TestLogSpoolOffset -----")
+	spoolPath := path.Join(t.TempDir(), "test.spool")
+	spool, err := openLogSpool(spoolPath, 1, newDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"第1行\n", "第2行\n", "第3行\n"} {
+		if _, err := spool.append([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, lines, err := spool.read(nil, 1024, 1)
+	if err != nil || lines != 1 || string(data) != "第1行\n" {
+		t.Fatalf("读取本地缓存不符合预期: %q, %d, %v", data, lines, err)
+	}
+	if err := spool.advance(len(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开后从上次的重放位置继续重放，已重放的日志不会重复读取
+	spool, err = openLogSpool(spoolPath, 1, newDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, lines, err = spool.read(nil, 1024, 0)
+	if err != nil || lines != 2 || string(data) != "第2行\n第3行\n" {
+		t.Fatalf("重新打开后读取本地缓存不符合预期: %q, %d, %v", data, lines, err)
+	}
+	if err := spool.advance(len(data)); err != nil {
+		t.Fatal(err)
+	}
+	if spool.pending() {
+		t.Fatal("全部重放后不应该有待重放的日志")
+	}
+	if err := spool.close(); err != nil {
+		t.Fatal(err)
+	}
+	// 全部重放后关闭时删除缓存文件与重放位置文件
+	for _, filePath := range []string{spoolPath, spoolPath + spoolOffsetFileExt} {
+		if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+			t.Fatalf("全部重放后关闭时应该删除 %s: %v", filePath, err)
+		}
+	}
+
+	// 重放位置超出缓存文件大小时从头重放
+	if err := os.WriteFile(spoolPath, []byte("第1行\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(spoolPath+spoolOffsetFileExt, []byte{0, 0, 0, 0, 0, 0, 1, 0}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	spool, err = openLogSpool(spoolPath, 1, newDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = spool.close()
+	}()
+	if spool.offset != 0 || !spool.pending() {
+		t.Fatalf("重放位置超出缓存文件大小时应该从头重放: offset=%d", spool.offset)
+	}
+}
@@ -0,0 +1,388 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/log_syslog.go syslog输出目标，按RFC 5424或RFC 3164格式将日志发送到本机或远程的syslog服务
+*/
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// syslog消息格式定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_SYSLOG_RFC5424 RFC 5424格式
+	LOG_SYSLOG_RFC5424 = iota + 1
+	// LOG_SYSLOG_RFC3164 RFC 3164(BSD syslog)格式
+	LOG_SYSLOG_RFC3164
+	// log_syslog_format_max syslog消息格式定义值上限
+	log_syslog_format_max
+)
+
+// syslog常用的facility定义
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_SYSLOG_FACILITY_KERN 内核消息，对应facility 0
+	//
+	//	-1是表示facility 0的特殊值: Facility为0时采用默认值LOG_SYSLOG_FACILITY_USER，
+	//	因此不能直接使用0或log/syslog包的syslog.LOG_KERN(同样为0)配置kern；Facility的有效取值为-1到23。
+	LOG_SYSLOG_FACILITY_KERN = -1
+	// LOG_SYSLOG_FACILITY_USER 用户程序
+	LOG_SYSLOG_FACILITY_USER = 1
+	// LOG_SYSLOG_FACILITY_DAEMON 系统守护进程
+	LOG_SYSLOG_FACILITY_DAEMON = 3
+	// LOG_SYSLOG_FACILITY_LOCAL0 本地自定义，LOCAL0到LOCAL7对应16到23
+	LOG_SYSLOG_FACILITY_LOCAL0 = 16
+	// log_syslog_facility_max syslog facility定义值上限
+	log_syslog_facility_max = 24
+)
+
+// 本机syslog服务的默认socket路径
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// 连接与写入syslog服务的超时时间
+const syslogDialTimeout = 3 * time.Second
+
+// syslog消息的分帧方式
+const (
+	// 数据报，每条消息一个数据报
+	syslogFramingDatagram = iota + 1
+	// 按RFC 6587的octet-counting分帧: "消息长度 消息"
+	syslogFramingOctetCounting
+	// 消息以换行结尾，消息中的换行替换为空格
+	syslogFramingLF
+)
+
+// SyslogConfig syslog输出目标配置
+type SyslogConfig struct {
+	// 网络类型: "unixgram","unix","udp","tcp"等，为空时连接本机syslog服务(/dev/log等)
+	Network string `json:"network" yaml:"network" mapstructure:"network"`
+	// syslog服务地址，如"127.0.0.1:514"或unix socket路径，Network为空时忽略
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+	// syslog消息格式，默认: LOG_SYSLOG_RFC5424; LOG_SYSLOG_RFC3164 BSD syslog格式
+	Format int `json:"format" yaml:"format" mapstructure:"format"`
+	// facility，取值1到23，默认: LOG_SYSLOG_FACILITY_USER; facility 0需要配置为LOG_SYSLOG_FACILITY_KERN(-1)
+	Facility int `json:"facility" yaml:"facility" mapstructure:"facility"`
+	// 应用名称，即RFC 5424的APP-NAME与RFC 3164的TAG，默认为程序文件名
+	Tag string `json:"tag" yaml:"tag" mapstructure:"tag"`
+	// 连接失败后的最小重连间隔，单位毫秒，默认: 100
+	ReconnectMinMilliSec int `json:"reconnect_min_milli_sec" yaml:"reconnect_min_milli_sec" mapstructure:"reconnect_min_milli_sec"`
+	// 连接失败后的最大重连间隔，单位毫秒，默认: 30000
+	ReconnectMaxMilliSec int `json:"reconnect_max_milli_sec" yaml:"reconnect_max_milli_sec" mapstructure:"reconnect_max_milli_sec"`
+}
+
+// 检查syslog输出目标配置
+func checkSyslogConfig(syslogConfig *SyslogConfig) error {
+	if syslogConfig == nil {
+		return fmt.Errorf("syslog输出目标配置不可为空")
+	}
+	if _, err := syslogFraming(syslogConfig.Network); err != nil {
+		return err
+	}
+	if syslogConfig.Network != "" && syslogConfig.Address == "" {
+		return fmt.Errorf("syslog服务地址不可为空")
+	}
+	if syslogConfig.Format != 0 && (syslogConfig.Format < LOG_SYSLOG_RFC5424 || syslogConfig.Format >= log_syslog_format_max) {
+		return fmt.Errorf("syslog消息格式不能超出有效范围: %d", syslogConfig.Format)
+	}
+	if syslogConfig.Facility < LOG_SYSLOG_FACILITY_KERN || syslogConfig.Facility >= log_syslog_facility_max {
+		return fmt.Errorf("syslog facility不能超出有效范围: %d, 有效取值为1到23, 0表示默认值LOG_SYSLOG_FACILITY_USER, facility 0(kern)需要配置为LOG_SYSLOG_FACILITY_KERN(-1)", syslogConfig.Facility)
+	}
+	if syslogConfig.ReconnectMinMilliSec < 0 || syslogConfig.ReconnectMaxMilliSec < 0 {
+		return fmt.Errorf("syslog重连间隔不能小于0")
+	}
+	return nil
+}
+
+// 根据网络类型决定syslog消息的分帧方式
+func syslogFraming(network string) (int, error) {
+	switch network {
+	case "", "unixgram", "udp", "udp4", "udp6":
+		return syslogFramingDatagram, nil
+	case "tcp", "tcp4", "tcp6":
+		return syslogFramingOctetCounting, nil
+	case "unix":
+		return syslogFramingLF, nil
+	default:
+		return 0, fmt.Errorf("不支持的syslog网络类型: %s", network)
+	}
+}
+
+// 日志级别对应的syslog severity
+func syslogSeverity(logLevel int) int {
+	switch logLevel {
+	case LOG_LEVEL_DEBUG:
+		return 7 // debug
+	case LOG_LEVEL_INFO:
+		return 6 // info
+	case LOG_LEVEL_WARNING:
+		return 4 // warning
+	case LOG_LEVEL_ERROR:
+		return 3 // err
+	case LOG_LEVEL_PANIC:
+		return 2 // crit
+	case LOG_LEVEL_FATAL:
+		return 1 // alert
+	default:
+		return 5 // notice
+	}
+}
+
+// 将syslog头部字段中的空白与不可打印字符替换为"_"，为空时返回"-"
+func syslogHeaderField(s string, maxLen int) string {
+	if s == "" {
+		return "-"
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c >= 0x7f {
+			b[i] = '_'
+		}
+	}
+	if len(b) > maxLen {
+		b = b[:maxLen]
+	}
+	return string(b)
+}
+
+// syslog输出目标
+//
+//	连接断开或写入失败时立即重连并重发一次，仍然失败时按指数退避间隔重连，等待重连期间的日志被丢弃，
+//	重新连接后在控制台输出丢弃的日志数量。
+type syslogSink struct {
+	lock    sync.Mutex
+	config  SyslogConfig
+	encoder logEncoder
+	framing int
+	// RFC 5424的HOSTNAME、APP-NAME与RFC 3164的HOSTNAME、TAG
+	hostname string
+	tag      string
+	// syslog消息缓冲区与分帧缓冲区，写入时复用
+	msgBuf []byte
+	buf    []byte
+
+	// 当前连接，未连接时为nil
+	conn net.Conn
+	// 重连的指数退避控制
+	backoff reconnectBackoff
+	// 等待重连期间丢弃的日志数量
+	dropped int
+}
+
+// NewSyslogSink 创建syslog输出目标
+//
+//	encoder 日志输出编码，为0时采用LOG_ENCODER_TEXT；lineFormat 日志行格式，为空时只输出日志内容与结构化字段。
+//	syslog服务暂时无法连接时不会返回错误，之后写入时按退避间隔重连。
+func NewSyslogSink(syslogConfig *SyslogConfig, encoder int, lineFormat string) (Sink, error) {
+	return newSyslogSink(syslogConfig, encoder, lineFormat)
+}
+
+func newSyslogSink(syslogConfig *SyslogConfig, encoder int, lineFormat string) (*syslogSink, error) {
+	if err := checkSyslogConfig(syslogConfig); err != nil {
+		return nil, err
+	}
+	if encoder == 0 {
+		encoder = LOG_ENCODER_TEXT
+	}
+	if encoder < LOG_ENCODER_TEXT || encoder >= log_encoder_max {
+		return nil, fmt.Errorf("日志输出编码不能超出有效范围: %d", encoder)
+	}
+	if lineFormat == "" {
+		// syslog消息头已包含时间、级别、主机名与进程ID
+		lineFormat = LOG_FORMAT_MSG
+	}
+	logEnc, err := newLogEncoder(encoder, lineFormat)
+	if err != nil {
+		return nil, err
+	}
+	s := &syslogSink{config: *syslogConfig, encoder: logEnc}
+	s.framing, _ = syslogFraming(s.config.Network)
+	if s.config.Format == 0 {
+		s.config.Format = LOG_SYSLOG_RFC5424
+	}
+	switch s.config.Facility {
+	case 0:
+		s.config.Facility = LOG_SYSLOG_FACILITY_USER
+	case LOG_SYSLOG_FACILITY_KERN:
+		s.config.Facility = 0
+	}
+	tag := s.config.Tag
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	s.hostname = syslogHeaderField(hostname, 255)
+	s.tag = syslogHeaderField(tag, 48)
+	s.backoff = newReconnectBackoff(s.config.ReconnectMinMilliSec, s.config.ReconnectMaxMilliSec)
+	_ = s.connect()
+	return s, nil
+}
+
+// 连接syslog服务，需要在持有lock时调用
+//
+//	连接失败时按指数退避计算下次允许重连的时间；连接本机syslog服务时，按实际连接的网络类型决定分帧方式。
+func (s *syslogSink) connect() error {
+	var conn net.Conn
+	var err error
+	network := s.config.Network
+	if network == "" {
+		conn, network, err = dialLocalSyslog()
+	} else {
+		conn, err = net.DialTimeout(s.config.Network, s.config.Address, syslogDialTimeout)
+	}
+	if err != nil {
+		s.backoff.fail()
+		return fmt.Errorf("连接syslog服务发生错误: %w", err)
+	}
+	s.conn = conn
+	s.framing, _ = syslogFraming(network)
+	s.backoff.reset()
+	if s.dropped > 0 {
+		fmt.Printf("zclog syslog服务已重新连接，等待重连期间丢弃日志 %d 条\n", s.dropped)
+		s.dropped = 0
+	}
+	return nil
+}
+
+// 连接本机syslog服务，依次尝试数据报与流式unix socket，返回实际连接的网络类型
+func dialLocalSyslog() (net.Conn, string, error) {
+	var errs []error
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, p := range syslogLocalPaths {
+			conn, err := net.DialTimeout(network, p, syslogDialTimeout)
+			if err == nil {
+				return conn, network, nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+// 关闭当前连接，需要在持有lock时调用
+func (s *syslogSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// 生成syslog消息
+func (s *syslogSink) appendMessage(buf []byte, msg *logMsg) []byte {
+	pri := s.config.Facility*8 + syslogSeverity(msg.logLevel)
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(pri), 10)
+	buf = append(buf, '>')
+	if s.config.Format == LOG_SYSLOG_RFC3164 {
+		// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+		buf = msg.pushTime.AppendFormat(buf, time.Stamp)
+		buf = append(buf, ' ')
+		buf = append(buf, s.hostname...)
+		buf = append(buf, ' ')
+		buf = append(buf, s.tag...)
+		buf = append(buf, '[')
+		buf = append(buf, processID...)
+		buf = append(buf, "]: "...)
+	} else {
+		// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+		buf = append(buf, "1 "...)
+		buf = msg.pushTime.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+		buf = append(buf, ' ')
+		buf = append(buf, s.hostname...)
+		buf = append(buf, ' ')
+		buf = append(buf, s.tag...)
+		buf = append(buf, ' ')
+		buf = append(buf, processID...)
+		buf = append(buf, " - - "...)
+	}
+	buf = s.encoder.encode(buf, msg)
+	for len(buf) > 0 && buf[len(buf)-1] == '\n' {
+		buf = buf[:len(buf)-1]
+	}
+	return buf
+}
+
+// 按分帧方式生成发送的数据
+func (s *syslogSink) frame(msg *logMsg) []byte {
+	s.msgBuf = s.appendMessage(s.msgBuf[:0], msg)
+	switch s.framing {
+	case syslogFramingOctetCounting:
+		s.buf = strconv.AppendInt(s.buf[:0], int64(len(s.msgBuf)), 10)
+		s.buf = append(s.buf, ' ')
+		s.buf = append(s.buf, s.msgBuf...)
+		return s.buf
+	case syslogFramingLF:
+		for i, c := range s.msgBuf {
+			if c == '\n' {
+				s.msgBuf[i] = ' '
+			}
+		}
+		s.msgBuf = append(s.msgBuf, '\n')
+	}
+	return s.msgBuf
+}
+
+func (s *syslogSink) Write(entry *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data := s.frame(entry.encodeMsg())
+	if s.conn != nil {
+		if err := s.send(data); err == nil {
+			return nil
+		}
+		// 连接可能已被syslog服务关闭，立即重连并重发一次
+		_ = s.closeConn()
+		s.backoff.reset()
+	}
+	if !s.backoff.ready() {
+		s.dropped++
+		return nil
+	}
+	if err := s.connect(); err != nil {
+		s.dropped++
+		return err
+	}
+	if err := s.send(data); err != nil {
+		_ = s.closeConn()
+		s.dropped++
+		return fmt.Errorf("日志发送到syslog服务发生错误: %w", err)
+	}
+	return nil
+}
+
+// 发送数据，需要在持有lock且已连接时调用
+func (s *syslogSink) send(data []byte) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(syslogDialTimeout))
+	_, err := s.conn.Write(data)
+	return err
+}
+
+func (s *syslogSink) Sync() error {
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closeConn()
+}
@@ -0,0 +1,329 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 生成测试用的日志条目
+func newTestEntry(logLevel int, msg string) *Entry {
+	return newEntry(&logMsg{pushTime: time.Now(), logLevel: logLevel, logMsg: msg})
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	fmt.Println("----- TestSyslogSeverity -----")
+	expected := map[int]int{
+		LOG_LEVEL_DEBUG:   7,
+		LOG_LEVEL_INFO:    6,
+		LOG_LEVEL_WARNING: 4,
+		LOG_LEVEL_ERROR:   3,
+		LOG_LEVEL_PANIC:   2,
+		LOG_LEVEL_FATAL:   1,
+	}
+	for level, severity := range expected {
+		if got := syslogSeverity(level); got != severity {
+			t.Fatalf("日志级别 %d 对应的syslog severity不符合预期: %d, 预期: %d", level, got, severity)
+		}
+	}
+	invalidConfigs := []*SyslogConfig{
+		nil,
+		{Network: "sctp", Address: "127.0.0.1:514"},
+		{Network: "udp"},
+		{Network: "udp", Address: "127.0.0.1:514", Format: log_syslog_format_max},
+		{Network: "udp", Address: "127.0.0.1:514", Facility: log_syslog_facility_max},
+		{Network: "udp", Address: "127.0.0.1:514", Facility: LOG_SYSLOG_FACILITY_KERN - 1},
+	}
+	for i, syslogConfig := range invalidConfigs {
+		config := newDefaultConfig()
+		config.LogSinks = []SinkConfig{{Type: LOG_SINK_SYSLOG, Syslog: syslogConfig}}
+		if _, err := CheckConfig(config); err == nil {
+			t.Fatalf("第%d个syslog配置应该校验失败", i+1)
+		} else {
+			fmt.Println(err)
+		}
+	}
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	fmt.Println("----- TestSyslogSinkUDP -----")
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = pc.Close()
+	}()
+	logger, err := NewLogger(&Config{
+		LogMod: LOG_MODE_LOCAL,
+		LogSinks: []SinkConfig{{
+			Type: LOG_SINK_SYSLOG,
+			Syslog: &SyslogConfig{
+				Network:  "udp",
+				Address:  pc.LocalAddr().String(),
+				Facility: LOG_SYSLOG_FACILITY_LOCAL0,
+				Tag:      "zcgolog-test",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Warnw("磁盘空间不足", "free", "1G")
+	_ = pc.SetReadDeadline(time.Now().Add(3 * time.Second))
+	data := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// local0(16)*8 + warning(4) = 132
+	pattern := `^<132>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}\S+ ` + regexp.QuoteMeta(hostname) +
+		` zcgolog-test ` + processID + ` - - 磁盘空间不足 free=1G$`
+	if !regexp.MustCompile(pattern).Match(data[:n]) {
+		t.Fatalf("RFC 5424格式的syslog消息不符合预期: %s", data[:n])
+	}
+}
+
+func TestSyslogSinkUnixgram(t *testing.T) {
+	fmt.Println("----- TestSyslogSinkUnixgram -----")
+	socketPath := path.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	sink, err := NewSyslogSink(&SyslogConfig{Network: "unixgram", Address: socketPath, Format: LOG_SYSLOG_RFC3164, Tag: "app"}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "本机syslog日志")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	data := make([]byte, 4096)
+	n, err := conn.Read(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// user(1)*8 + info(6) = 14
+	pattern := `^<14>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} ` + regexp.QuoteMeta(hostname) + ` app\[` + processID + `\]: 本机syslog日志$`
+	if !regexp.MustCompile(pattern).Match(data[:n]) {
+		t.Fatalf("RFC 3164格式的syslog消息不符合预期: %s", data[:n])
+	}
+
+	// facility 0通过LOG_SYSLOG_FACILITY_KERN配置
+	kernSink, err := NewSyslogSink(&SyslogConfig{Network: "unixgram", Address: socketPath, Format: LOG_SYSLOG_RFC3164, Facility: LOG_SYSLOG_FACILITY_KERN, Tag: "app"}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = kernSink.Close()
+	}()
+	if err := kernSink.Write(newTestEntry(LOG_LEVEL_INFO, "kern日志")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err = conn.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	// kern(0)*8 + info(6) = 6
+	if !strings.HasPrefix(string(data[:n]), "<6>") {
+		t.Fatalf("facility为LOG_SYSLOG_FACILITY_KERN时的syslog消息不符合预期: %s", data[:n])
+	}
+}
+
+func TestSyslogSinkLocalStream(t *testing.T) {
+	fmt.Println("----- TestSyslogSinkLocalStream -----")
+	socketPath := path.Join(t.TempDir(), "log.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	origPaths := syslogLocalPaths
+	syslogLocalPaths = []string{socketPath}
+	defer func() {
+		syslogLocalPaths = origPaths
+	}()
+	// 本机syslog服务只提供流式unix socket时，按换行分帧
+	sink, err := NewSyslogSink(&SyslogConfig{Format: LOG_SYSLOG_RFC3164, Tag: "app"}, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	for _, msg := range []string{"第一行\n第二行", "第二条日志"} {
+		if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, expected := range []string{"app[" + processID + "]: 第一行 第二行\n", "app[" + processID + "]: 第二条日志\n"} {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(line, "<14>") || !strings.HasSuffix(line, expected) {
+			t.Fatalf("流式unix socket的syslog消息应该以换行分隔: %q", line)
+		}
+	}
+}
+
+// 读取一条octet-counting分帧的syslog消息
+func readOctetCountingFrame(r *bufio.Reader) (string, error) {
+	msgLen, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(msgLen, " "))
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func TestSyslogSinkTCPReconnect(t *testing.T) {
+	fmt.Println("----- TestSyslogSinkTCPReconnect -----")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	msgs := make(chan string, 100)
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					msg, err := readOctetCountingFrame(r)
+					if err != nil {
+						return
+					}
+					msgs <- msg
+				}
+			}()
+		}
+	}()
+	sink, err := NewSyslogSink(&SyslogConfig{
+		Network:              "tcp",
+		Address:              listener.Addr().String(),
+		Tag:                  "app",
+		ReconnectMinMilliSec: 10,
+	}, LOG_ENCODER_TEXT, "%level %msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = sink.Close()
+	}()
+	waitMsg := func(keyword string) bool {
+		select {
+		case msg := <-msgs:
+			return strings.Contains(msg, keyword)
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+	if err := sink.Write(newTestEntry(LOG_LEVEL_ERROR, "第一行\n第二行")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-msgs:
+		// user(1)*8 + err(3) = 11，octet-counting分帧时消息中可以包含换行
+		if !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, " - - [ERROR] 第一行\n第二行") {
+			t.Fatalf("TCP发送的syslog消息不符合预期: %q", msg)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到TCP发送的syslog消息")
+	}
+
+	// syslog服务关闭连接后，重新连接并继续发送
+	_ = (<-conns).Close()
+	for i := 0; i < 50; i++ {
+		_ = sink.Write(newTestEntry(LOG_LEVEL_INFO, "重连后的日志"))
+		if waitMsg("重连后的日志") {
+			return
+		}
+	}
+	t.Fatal("syslog服务关闭连接后应该重新连接")
+}
+
+func TestSyslogSinkBackoff(t *testing.T) {
+	fmt.Println("----- TestSyslogSinkBackoff -----")
+	// 获取一个没有监听的端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	sink, err := newSyslogSink(&SyslogConfig{Network: "tcp", Address: addr, ReconnectMinMilliSec: 200, ReconnectMaxMilliSec: 400}, 0, "")
+	if err != nil {
+		t.Fatal("syslog服务无法连接时不应该返回错误")
+	}
+	// 等待重连期间的日志直接丢弃，不会每次都尝试连接
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "丢弃的日志")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sink.dropped != 10 || sink.backoff.cur != 400*time.Millisecond {
+		t.Fatalf("等待重连期间的状态不符合预期: dropped=%d, backoff=%s", sink.dropped, sink.backoff.cur)
+	}
+	// 到达重连时间后尝试连接，连接失败时返回错误，重连间隔不超过上限
+	time.Sleep(250 * time.Millisecond)
+	if err := sink.Write(newTestEntry(LOG_LEVEL_INFO, "丢弃的日志")); err == nil {
+		t.Fatal("重连失败时应该返回错误")
+	}
+	if sink.dropped != 11 || sink.backoff.cur != 400*time.Millisecond {
+		t.Fatalf("重连失败后的状态不符合预期: dropped=%d, backoff=%s", sink.dropped, sink.backoff.cur)
+	}
+}
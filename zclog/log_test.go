@@ -177,7 +177,7 @@ func writeLog10000() {
 		Debugf("测试写入日志writeLog10000writeLog10000writeLog10000writeLog10000writeLog10000: %d", i+1)
 	}
 	for {
-		if len(logMsgChn) == 0 {
+		if len(defaultLogger.logMsgChn) == 0 {
 			break
 		}
 		time.Sleep(1 * time.Second)
@@ -19,7 +19,6 @@ import (
 	"os"
 	"path"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -28,13 +27,96 @@ const (
 	OS_OUT_STDOUT = "OS.STDOUT"
 )
 
-// GetLogFilePathAndYMDToday 获取日志文件路径和当天年月日。
+// 日志文件按时间滚动的周期定义
 //
-//	不存在当天对应日志文件时，创建新的日志文件；
-//	存在当天对应日志文件时，获取最新的日志文件；
-//	最新日志文件大小超过配置的日志文件大小上限时，创建新的日志文件。
-//	每天的日志文件数量不能超过99999，否则会报错。
-//	本地模式下，如果LogFileDir为空，则返回 ("OS.STDOUT", 当天日期, nil)表示只能输出到控制台。
+//goland:noinspection GoSnakeCaseUsage
+const (
+	// LOG_ROTATE_DAILY 按天滚动，日志文件名中的滚动周期标识格式: yyyyMMdd
+	LOG_ROTATE_DAILY = iota + 1
+	// LOG_ROTATE_HOURLY 按小时滚动，日志文件名中的滚动周期标识格式: yyyyMMddHH
+	LOG_ROTATE_HOURLY
+	// LOG_ROTATE_WEEKLY 按周滚动，每周从周一开始，日志文件名中的滚动周期标识为周一的日期，格式: yyyyMMdd
+	LOG_ROTATE_WEEKLY
+	// log_rotate_max 日志文件滚动周期定义值上限
+	log_rotate_max
+)
+
+// 获取滚动周期的时长(分钟)，滚动时间偏移不能超过该时长
+func rotateIntervalMinutes(interval int) int {
+	switch interval {
+	case LOG_ROTATE_HOURLY:
+		return 60
+	case LOG_ROTATE_WEEKLY:
+		return 7 * 24 * 60
+	default:
+		return 24 * 60
+	}
+}
+
+// 获取指定时间所在滚动周期的起始时间
+//
+//	offsetMinutes 滚动时间偏移(分钟)，如按天滚动、偏移120分钟时，滚动周期为每天02:00到次日02:00
+func getRotatePeriodStart(t time.Time, interval int, offsetMinutes int) time.Time {
+	offset := time.Duration(offsetMinutes) * time.Minute
+	shifted := t.Add(-offset)
+	var start time.Time
+	switch interval {
+	case LOG_ROTATE_HOURLY:
+		start = time.Date(shifted.Year(), shifted.Month(), shifted.Day(), shifted.Hour(), 0, 0, 0, shifted.Location())
+	case LOG_ROTATE_WEEKLY:
+		// 每周从周一开始
+		daysSinceMonday := (int(shifted.Weekday()) + 6) % 7
+		start = time.Date(shifted.Year(), shifted.Month(), shifted.Day()-daysSinceMonday, 0, 0, 0, 0, shifted.Location())
+	default:
+		start = time.Date(shifted.Year(), shifted.Month(), shifted.Day(), 0, 0, 0, 0, shifted.Location())
+	}
+	return start.Add(offset)
+}
+
+// 获取指定时间之后的下次滚动时间，即所在滚动周期的结束时间
+func getNextRotateTime(logConfig *Config, t time.Time) time.Time {
+	start := getRotatePeriodStart(t, logConfig.LogFileRotateInterval, logConfig.LogFileRotateOffsetMinutes)
+	switch logConfig.LogFileRotateInterval {
+	case LOG_ROTATE_HOURLY:
+		return start.Add(time.Hour)
+	case LOG_ROTATE_WEEKLY:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// 获取滚动周期标识的日期格式
+//
+//	未配置LogFileDateLayout时，按小时滚动为 yyyyMMddHH ，按天与按周滚动为 yyyyMMdd 。
+func getRotateDateLayout(logConfig *Config) string {
+	if logConfig.LogFileDateLayout != "" {
+		return logConfig.LogFileDateLayout
+	}
+	if logConfig.LogFileRotateInterval == LOG_ROTATE_HOURLY {
+		return "2006010215"
+	}
+	return "20060102"
+}
+
+// 根据日志配置获取指定时间所在滚动周期的标识，用于日志文件名
+//
+//	标识取自不含时间偏移的周期起始时间，如按天滚动、偏移120分钟时，2022-05-08 01:00 属于滚动周期 20220507
+func getRotatePeriod(logConfig *Config, t time.Time) string {
+	offset := time.Duration(logConfig.LogFileRotateOffsetMinutes) * time.Minute
+	start := getRotatePeriodStart(t, logConfig.LogFileRotateInterval, logConfig.LogFileRotateOffsetMinutes).Add(-offset)
+	return start.Format(getRotateDateLayout(logConfig))
+}
+
+// GetLogFilePathAndYMDToday 获取日志文件路径和当前滚动周期标识。
+//
+//	滚动周期标识由LogFileRotateInterval决定，默认按天滚动，此时即为当天年月日；
+//	不存在当前滚动周期对应日志文件时，创建新的日志文件；
+//	存在当前滚动周期对应日志文件时，获取最新的日志文件；
+//	最新日志文件大小超过配置的日志文件大小上限，或已被压缩时，创建新的日志文件。
+//	日志文件名由LogFileNamePattern等配置决定，默认为 [LogFileNamePrefix]_[滚动周期标识]_[%05d].log ；
+//	每个滚动周期的日志文件数量不能超过序列号宽度允许的最大值(默认99999)，否则会报错。
+//	本地模式下，如果LogFileDir为空，则返回 ("OS.STDOUT", 当前滚动周期标识, nil)表示只能输出到控制台。
 func GetLogFilePathAndYMDToday(logConfig *Config) (string, string, error) {
 	// 检查日志配置
 	res, err := CheckConfig(logConfig)
@@ -43,44 +125,49 @@ func GetLogFilePathAndYMDToday(logConfig *Config) (string, string, error) {
 	}
 	// 检查通过但没有LogFileDir，此时只能输出到控制台
 	if res == CONFIG_CHECK_RESULT_NOFILEDIR {
-		return OS_OUT_STDOUT, getYMDToday(), nil
+		return OS_OUT_STDOUT, getRotatePeriod(logConfig, time.Now()), nil
 	}
 	// 防止日志文件目录尚未创建
-	_ = os.MkdirAll(logConfig.LogFileDir, os.ModePerm)
+	if err := ensureLogFileDir(logConfig); err != nil {
+		return "", "", err
+	}
 	// 读取日志目录下所有文件
 	files, err := os.ReadDir(logConfig.LogFileDir)
 	if err != nil {
 		return "", "", err
 	}
-	// 获取当天年月日，并生成日志文件前缀
-	ymdToday := getYMDToday()
-	prefix := logConfig.LogFileNamePrefix + "_" + ymdToday + "_"
-	var fileNames []string
-	// 过滤当天日志
-	for _, f := range files {
-		fileName := f.Name()
-		if strings.HasPrefix(fileName, prefix) && strings.HasSuffix(fileName, ".log") {
-			fileNames = append(fileNames, fileName)
-		}
+	namer, err := parseLogFileNamePattern(logConfig)
+	if err != nil {
+		return "", "", err
 	}
-	// 查找最新日志文件
+	// 获取当前滚动周期标识
+	ymdToday := getRotatePeriod(logConfig, time.Now())
+	nameRegexp := namer.regexp(ymdToday)
+	// 查找当前滚动周期的最新日志文件，压缩后的日志文件也参与序列号计算
 	maxNumber := 0
 	lastFileName := ""
-	for _, filename := range fileNames {
-		suffixIndex := strings.LastIndex(filename, ".log")
-		tmp := filename[0:suffixIndex]
-		arrs := strings.Split(tmp, "_")
-		numberStr := arrs[len(arrs)-1]
-		number, err := strconv.Atoi(numberStr)
+	lastCompressed := false
+	for _, f := range files {
+		matches := nameRegexp.FindStringSubmatch(f.Name())
+		if matches == nil {
+			continue
+		}
+		number, err := strconv.Atoi(matches[1])
 		if err != nil {
 			continue
 		}
+		compressed := matches[2] != ""
 		if number > maxNumber {
 			maxNumber = number
-			lastFileName = filename
+			lastFileName = f.Name()
+			lastCompressed = compressed
+		} else if number == maxNumber && compressed {
+			// 同一序列号存在压缩后的文件时，说明该日志文件已经滚动
+			lastCompressed = true
 		}
 	}
-	if maxNumber > 0 {
+	// 最新日志文件已压缩时不能继续写入
+	if maxNumber > 0 && !lastCompressed {
 		lastFilePath := path.Join(logConfig.LogFileDir, lastFileName)
 		// 读取最新文件状态
 		lastFileState, err := os.Stat(lastFilePath)
@@ -92,17 +179,17 @@ func GetLogFilePathAndYMDToday(logConfig *Config) (string, string, error) {
 			return lastFilePath, ymdToday, nil
 		}
 	}
-	// 当天没有日志文件，或最新日志文件大小已超出上限时，需要创建新的日志文件
-	numberToday, err := nextNumberToday(maxNumber)
+	// 当前滚动周期没有日志文件，或最新日志文件大小已超出上限时，需要创建新的日志文件
+	numberToday, err := nextNumberToday(maxNumber, namer.maxSeq())
 	if err != nil {
 		return "", ymdToday, err
 	}
-	targetFileName := prefix + numberToday + ".log"
-	targetFilePath := path.Join(logConfig.LogFileDir, targetFileName)
-	err = os.WriteFile(targetFilePath, []byte{}, 0644)
+	targetFilePath := path.Join(logConfig.LogFileDir, namer.fileName(ymdToday, numberToday))
+	targetFile, err := createLogFile(logConfig, targetFilePath, os.O_WRONLY)
 	if err != nil {
 		return "", ymdToday, err
 	}
+	_ = targetFile.Close()
 	return targetFilePath, ymdToday, nil
 }
 
@@ -112,15 +199,15 @@ func getYMDToday() string {
 	return fmt.Sprintf("%d%02d%02d", now.Year(), now.Month(), now.Day())
 }
 
-// 当天日志序列号+1
+// 当前滚动周期日志序列号+1
 //
-//	不能超过99999，否则报错
-func nextNumberToday(maxNumber int) (string, error) {
+//	不能超过序列号宽度允许的最大值(默认宽度5，即99999)，否则报错
+func nextNumberToday(maxNumber int, maxSeq int) (int, error) {
 	number := maxNumber + 1
-	if number > 99999 {
-		return "", fmt.Errorf("当天日志文件数量超过上限:99999")
+	if number > maxSeq {
+		return 0, fmt.Errorf("当前滚动周期日志文件数量超过上限:%d", maxSeq)
 	}
-	return fmt.Sprintf("%05d", number), nil
+	return number, nil
 }
 
 func ClearDir(dirPath string) error {
@@ -14,13 +14,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFirstLog(t *testing.T) {
-	zcgologConfig.LogFileDir = "testdata/firstlog/"
-	logFileName, _, err := GetLogFilePathAndYMDToday(zcgologConfig)
+	defaultLogger.config.LogFileDir = "testdata/firstlog/"
+	logFileName, _, err := GetLogFilePathAndYMDToday(defaultLogger.config)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -46,9 +49,9 @@ func TestLastLog(t *testing.T) {
 	fileState, _ := os.Stat(logLast)
 	fmt.Printf("logLast文件大小: %d\n", fileState.Size())
 
-	zcgologConfig.LogFileDir = "testdata/lastlog/"
-	zcgologConfig.LogFileMaxSizeM = 1
-	logFileName, _, err := GetLogFilePathAndYMDToday(zcgologConfig)
+	defaultLogger.config.LogFileDir = "testdata/lastlog/"
+	defaultLogger.config.LogFileMaxSizeM = 1
+	logFileName, _, err := GetLogFilePathAndYMDToday(defaultLogger.config)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -62,6 +65,111 @@ func TestGetYMDToday(t *testing.T) {
 	fmt.Println(ymd)
 }
 
+func TestGetRotatePeriod(t *testing.T) {
+	fmt.Println("----- TestGetRotatePeriod -----")
+	// 2022-05-08 是周日
+	loc := time.Local
+	tests := []struct {
+		interval      int
+		offsetMinutes int
+		t             time.Time
+		wantLabel     string
+		wantStart     time.Time
+	}{
+		{LOG_ROTATE_DAILY, 0, time.Date(2022, 5, 8, 0, 0, 0, 0, loc), "20220508", time.Date(2022, 5, 8, 0, 0, 0, 0, loc)},
+		{LOG_ROTATE_DAILY, 0, time.Date(2022, 5, 8, 23, 59, 59, 0, loc), "20220508", time.Date(2022, 5, 8, 0, 0, 0, 0, loc)},
+		{LOG_ROTATE_DAILY, 120, time.Date(2022, 5, 8, 1, 59, 0, 0, loc), "20220507", time.Date(2022, 5, 7, 2, 0, 0, 0, loc)},
+		{LOG_ROTATE_DAILY, 120, time.Date(2022, 5, 8, 2, 0, 0, 0, loc), "20220508", time.Date(2022, 5, 8, 2, 0, 0, 0, loc)},
+		{LOG_ROTATE_HOURLY, 0, time.Date(2022, 5, 8, 13, 25, 0, 0, loc), "2022050813", time.Date(2022, 5, 8, 13, 0, 0, 0, loc)},
+		{LOG_ROTATE_HOURLY, 30, time.Date(2022, 5, 8, 13, 25, 0, 0, loc), "2022050812", time.Date(2022, 5, 8, 12, 30, 0, 0, loc)},
+		{LOG_ROTATE_WEEKLY, 0, time.Date(2022, 5, 8, 23, 0, 0, 0, loc), "20220502", time.Date(2022, 5, 2, 0, 0, 0, 0, loc)},
+		{LOG_ROTATE_WEEKLY, 0, time.Date(2022, 5, 9, 0, 0, 0, 0, loc), "20220509", time.Date(2022, 5, 9, 0, 0, 0, 0, loc)},
+		{LOG_ROTATE_WEEKLY, 60, time.Date(2022, 5, 9, 0, 30, 0, 0, loc), "20220502", time.Date(2022, 5, 2, 1, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		label := getRotatePeriod(&Config{LogFileRotateInterval: tt.interval, LogFileRotateOffsetMinutes: tt.offsetMinutes}, tt.t)
+		if label != tt.wantLabel {
+			t.Fatalf("滚动周期标识不符合预期, interval: %d, offset: %d, time: %s, 实际: %s, 预期: %s", tt.interval, tt.offsetMinutes, tt.t, label, tt.wantLabel)
+		}
+		start := getRotatePeriodStart(tt.t, tt.interval, tt.offsetMinutes)
+		if !start.Equal(tt.wantStart) {
+			t.Fatalf("滚动周期起始时间不符合预期, interval: %d, offset: %d, time: %s, 实际: %s, 预期: %s", tt.interval, tt.offsetMinutes, tt.t, start, tt.wantStart)
+		}
+	}
+}
+
+func TestGetNextRotateTime(t *testing.T) {
+	fmt.Println("----- TestGetNextRotateTime -----")
+	loc := time.Local
+	tests := []struct {
+		interval      int
+		offsetMinutes int
+		t             time.Time
+		want          time.Time
+	}{
+		{LOG_ROTATE_DAILY, 0, time.Date(2022, 5, 8, 13, 25, 0, 0, loc), time.Date(2022, 5, 9, 0, 0, 0, 0, loc)},
+		{LOG_ROTATE_DAILY, 120, time.Date(2022, 5, 8, 1, 0, 0, 0, loc), time.Date(2022, 5, 8, 2, 0, 0, 0, loc)},
+		{LOG_ROTATE_DAILY, 120, time.Date(2022, 5, 8, 2, 0, 0, 0, loc), time.Date(2022, 5, 9, 2, 0, 0, 0, loc)},
+		{LOG_ROTATE_HOURLY, 0, time.Date(2022, 5, 8, 23, 25, 0, 0, loc), time.Date(2022, 5, 9, 0, 0, 0, 0, loc)},
+		{LOG_ROTATE_WEEKLY, 0, time.Date(2022, 5, 8, 23, 0, 0, 0, loc), time.Date(2022, 5, 9, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		config := &Config{LogFileRotateInterval: tt.interval, LogFileRotateOffsetMinutes: tt.offsetMinutes}
+		if next := getNextRotateTime(config, tt.t); !next.Equal(tt.want) {
+			t.Fatalf("下次滚动时间不符合预期, interval: %d, offset: %d, time: %s, 实际: %s, 预期: %s", tt.interval, tt.offsetMinutes, tt.t, next, tt.want)
+		}
+	}
+}
+
+func TestRotateIntervalConfig(t *testing.T) {
+	fmt.Println("----- TestRotateIntervalConfig -----")
+	logDir := t.TempDir()
+	config := newDefaultConfig()
+	config.LogFileDir = logDir
+	config.LogFileRotateInterval = LOG_ROTATE_HOURLY
+	logFilePath, period, err := GetLogFilePathAndYMDToday(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(period) != len("2006010215") || filepath.Base(logFilePath) != "zcgolog_"+period+"_00001.log" {
+		t.Fatalf("按小时滚动时日志文件名不符合预期: %s", logFilePath)
+	}
+
+	config.LogFileRotateOffsetMinutes = 60
+	if _, err := CheckConfig(config); err == nil || !strings.Contains(err.Error(), "滚动时间偏移") {
+		t.Fatal("滚动时间偏移超出滚动周期时应该校验失败")
+	}
+	config.LogFileRotateOffsetMinutes = 0
+	config.LogFileRotateInterval = log_rotate_max
+	if _, err := CheckConfig(config); err == nil {
+		t.Fatal("滚动周期超出有效范围时应该校验失败")
+	}
+	// 未设置滚动周期时按天滚动
+	config.LogFileRotateInterval = 0
+	if _, err := CheckConfig(config); err != nil || config.LogFileRotateInterval != LOG_ROTATE_DAILY {
+		t.Fatalf("未设置滚动周期时应该按天滚动: %d, %v", config.LogFileRotateInterval, err)
+	}
+}
+
+func TestGetLogFilePathWithBaselineConfig(t *testing.T) {
+	fmt.Println("----- TestGetLogFilePathWithBaselineConfig -----")
+	// 只设置早期版本已有配置项的配置，后来新增的配置项采用默认值
+	logDir := t.TempDir()
+	logFilePath, period, err := GetLogFilePathAndYMDToday(&Config{
+		LogFileDir:        logDir,
+		LogFileNamePrefix: "x",
+		LogFileMaxSizeM:   2,
+		LogLevelGlobal:    LOG_LEVEL_DEBUG,
+		LogMod:            LOG_MODE_LOCAL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logFilePath != filepath.Join(logDir, "x_"+period+"_00001.log") {
+		t.Fatalf("日志文件路径不符合预期: %s", logFilePath)
+	}
+}
+
 func writeTestLog(logFilePath string, msg string) {
 	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
@@ -0,0 +1,424 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/logger_api.go 提供Logger实例的日志输出接口方法，与api.go中的包级别函数一一对应
+*/
+
+import (
+	"context"
+	"fmt"
+)
+
+// Print 日志级别: DEBUG
+func (l *Logger) Print(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Printf 日志级别: DEBUG
+func (l *Logger) Printf(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Println 日志级别: DEBUG
+func (l *Logger) Println(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// PrintWithCallerDepth 日志级别: DEBUG
+func (l *Logger) PrintWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// PrintfWithCallerDepth 日志级别: DEBUG
+func (l *Logger) PrintfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// PrintlnWithCallerDepth 日志级别: DEBUG
+func (l *Logger) PrintlnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// Debug 输出Debug日志
+func (l *Logger) Debug(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Debugf 输出Debug日志
+func (l *Logger) Debugf(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Debugln 输出Debug日志
+func (l *Logger) Debugln(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// DebugStack 输出调用栈(DEBUG)
+func (l *Logger) DebugStack(headMsg string) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
+}
+
+// DebugWithCallerDepth 输出Debug日志
+func (l *Logger) DebugWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// DebugfWithCallerDepth 输出Debug日志
+func (l *Logger) DebugfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// DebuglnWithCallerDepth 输出Debug日志
+func (l *Logger) DebuglnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// DebugStackWithCallerDepth 输出调用栈(DEBUG)
+func (l *Logger) DebugStackWithCallerDepth(callerDepth int, headMsg string) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Debugw 输出Debug日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// DebugwWithCallerDepth 输出Debug日志，附带结构化字段
+func (l *Logger) DebugwWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// DebugCtx 输出Debug日志，附带上下文字段
+func (l *Logger) DebugCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// DebugfCtx 输出Debug日志，附带上下文字段
+func (l *Logger) DebugfCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_DEBUG
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
+}
+
+// Info 输出Info日志
+func (l *Logger) Info(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Infof 输出Info日志
+func (l *Logger) Infof(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Infoln 输出Info日志
+func (l *Logger) Infoln(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// InfoStack 输出调用栈(INFO)
+func (l *Logger) InfoStack(headMsg string) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
+}
+
+// InfoWithCallerDepth 输出Info日志
+func (l *Logger) InfoWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// InfofWithCallerDepth 输出Info日志
+func (l *Logger) InfofWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// InfolnWithCallerDepth 输出Info日志
+func (l *Logger) InfolnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// InfoStackWithCallerDepth 输出调用栈(INFO)
+func (l *Logger) InfoStackWithCallerDepth(callerDepth int, headMsg string) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Infow 输出Info日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// InfowWithCallerDepth 输出Info日志，附带结构化字段
+func (l *Logger) InfowWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// InfoCtx 输出Info日志，附带上下文字段
+func (l *Logger) InfoCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// InfofCtx 输出Info日志，附带上下文字段
+func (l *Logger) InfofCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_INFO
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
+}
+
+// Warn 输出Warn日志
+func (l *Logger) Warn(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Warnf 输出Warn日志
+func (l *Logger) Warnf(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Warnln 输出Warn日志
+func (l *Logger) Warnln(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// WarnStack 输出调用栈(WARNING)
+func (l *Logger) WarnStack(headMsg string) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
+}
+
+// WarnWithCallerDepth 输出Warn日志
+func (l *Logger) WarnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// WarnfWithCallerDepth 输出Warn日志
+func (l *Logger) WarnfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// WarnlnWithCallerDepth 输出Warn日志
+func (l *Logger) WarnlnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// WarnStackWithCallerDepth 输出调用栈(WARNING)
+func (l *Logger) WarnStackWithCallerDepth(callerDepth int, headMsg string) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Warnw 输出Warn日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// WarnwWithCallerDepth 输出Warn日志，附带结构化字段
+func (l *Logger) WarnwWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// WarnCtx 输出Warn日志，附带上下文字段
+func (l *Logger) WarnCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// WarnfCtx 输出Warn日志，附带上下文字段
+func (l *Logger) WarnfCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_WARNING
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
+}
+
+// Error 输出Error日志
+func (l *Logger) Error(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Errorf 输出Error日志
+func (l *Logger) Errorf(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Errorln 输出Error日志
+func (l *Logger) Errorln(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// ErrorStack 输出调用栈(ERROR)
+func (l *Logger) ErrorStack(headMsg string) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLog(msgLogLevel, callerStackMsg(2, headMsg), CALLER_DEPTH_DEFAULT)
+}
+
+// ErrorWithCallerDepth 输出Error日志
+func (l *Logger) ErrorWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// ErrorfWithCallerDepth 输出Error日志
+func (l *Logger) ErrorfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// ErrorlnWithCallerDepth 输出Error日志
+func (l *Logger) ErrorlnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// ErrorStackWithCallerDepth 输出调用栈(ERROR)
+func (l *Logger) ErrorStackWithCallerDepth(callerDepth int, headMsg string) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLog(msgLogLevel, callerStackMsg(callerDepth, headMsg), callerDepth)
+}
+
+// Errorw 输出Error日志，附带结构化字段
+//
+//	keysAndValues 可以是Field，也可以是交替出现的字符串key与任意类型value
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, nil, buildFields(keysAndValues), false, nil)
+}
+
+// ErrorwWithCallerDepth 输出Error日志，附带结构化字段
+func (l *Logger) ErrorwWithCallerDepth(callerDepth int, msg string, keysAndValues ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLogWithFields(msgLogLevel, msg, callerDepth, nil, buildFields(keysAndValues), false, nil)
+}
+
+// ErrorCtx 输出Error日志，附带上下文字段
+func (l *Logger) ErrorCtx(ctx context.Context, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLogWithFields(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT, ctx, nil, false, nil)
+}
+
+// ErrorfCtx 输出Error日志，附带上下文字段
+func (l *Logger) ErrorfCtx(ctx context.Context, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_ERROR
+	l.outputLogWithFields(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, ctx, nil, true, params)
+}
+
+// Panic 直接输出日志，终止当前goroutine
+func (l *Logger) Panic(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_PANIC
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Panicf 直接输出日志，终止当前goroutine
+func (l *Logger) Panicf(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_PANIC
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Panicln 直接输出日志，终止当前goroutine
+func (l *Logger) Panicln(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_PANIC
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// PanicWithCallerDepth 直接输出日志，终止当前goroutine
+func (l *Logger) PanicWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_PANIC
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// PanicfWithCallerDepth 直接输出日志，终止当前goroutine
+func (l *Logger) PanicfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_PANIC
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// PaniclnWithCallerDepth 直接输出日志，终止当前goroutine
+func (l *Logger) PaniclnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_PANIC
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// Fatal 直接输出日志，终止程序
+func (l *Logger) Fatal(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_FATAL
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// Fatalf 直接输出日志，终止程序
+func (l *Logger) Fatalf(msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_FATAL
+	l.outputLogf(msgLogLevel, msg, CALLER_DEPTH_DEFAULT, params...)
+}
+
+// Fatalln 直接输出日志，终止程序
+func (l *Logger) Fatalln(v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_FATAL
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), CALLER_DEPTH_DEFAULT)
+}
+
+// FatalWithCallerDepth 直接输出日志，终止程序
+func (l *Logger) FatalWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_FATAL
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
+
+// FatalfWithCallerDepth 直接输出日志，终止程序
+func (l *Logger) FatalfWithCallerDepth(callerDepth int, msg string, params ...interface{}) {
+	msgLogLevel := LOG_LEVEL_FATAL
+	l.outputLogf(msgLogLevel, msg, callerDepth, params...)
+}
+
+// FatallnWithCallerDepth 直接输出日志，终止程序
+func (l *Logger) FatallnWithCallerDepth(callerDepth int, v ...interface{}) {
+	msgLogLevel := LOG_LEVEL_FATAL
+	l.outputLog(msgLogLevel, fmt.Sprint(v...), callerDepth)
+}
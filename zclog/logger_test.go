@@ -0,0 +1,144 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogger(t *testing.T) {
+	fmt.Println("----- TestNewLogger -----")
+	auditDir := filepath.Join(t.TempDir(), "audit")
+	accessDir := filepath.Join(t.TempDir(), "access")
+	auditLogger, err := NewLogger(&Config{
+		LogForbidStdout:   true,
+		LogFileDir:        auditDir,
+		LogFileNamePrefix: "audit",
+		LogLevelGlobal:    LOG_LEVEL_DEBUG,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessLogger, err := NewLogger(&Config{
+		LogForbidStdout:   true,
+		LogFileDir:        accessDir,
+		LogFileNamePrefix: "access",
+		LogMod:            LOG_MODE_SERVER,
+		LogEncoder:        LOG_ENCODER_JSON,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		auditLogger.Debugf("审计日志: %d", i+1)
+		accessLogger.Debugf("访问日志(DEBUG不输出): %d", i+1)
+		accessLogger.Infow("访问日志", "seq", i+1)
+	}
+	for len(accessLogger.logMsgChn) > 0 {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := accessLogger.QuitMsgReader(3000); err != nil {
+		t.Fatal(err)
+	}
+	auditLogger.closeCurrentLogFile()
+
+	auditContent := readSingleLogFile(t, auditDir, "audit_")
+	if strings.Count(auditContent, "审计日志") != 10 || strings.Contains(auditContent, "访问日志") {
+		t.Fatalf("审计日志内容不符合预期:\n%s", auditContent)
+	}
+	accessContent := readSingleLogFile(t, accessDir, "access_")
+	if strings.Count(accessContent, `"msg":"访问日志"`) != 10 || strings.Contains(accessContent, "DEBUG不输出") || strings.Contains(accessContent, "审计日志") {
+		t.Fatalf("访问日志内容不符合预期:\n%s", accessContent)
+	}
+	if auditLogger.GetLevel() != LOG_LEVEL_DEBUG || accessLogger.GetLevel() != LOG_LEVEL_INFO {
+		t.Fatal("各Logger的全局日志级别应该相互独立")
+	}
+}
+
+func TestNewLoggerInvalidConfig(t *testing.T) {
+	fmt.Println("----- TestNewLoggerInvalidConfig -----")
+	_, err := NewLogger(&Config{LogLineFormat: "%level %message"})
+	if err == nil {
+		t.Fatal("日志行格式不合法时应该返回错误")
+	}
+	fmt.Println(err)
+	_, err = NewLogger(&Config{LogMod: LOG_MODE_SERVER})
+	if err == nil {
+		t.Fatal("服务器模式下日志目录为空时应该返回错误")
+	}
+	fmt.Println(err)
+}
+
+// 读取目录下唯一的日志文件内容
+func readSingleLogFile(t *testing.T, dir string, prefix string) string {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !strings.HasPrefix(files[0].Name(), prefix) {
+		t.Fatalf("目录 %s 下应该只有一个以 %s 开头的日志文件", dir, prefix)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestLoggerWith(t *testing.T) {
+	fmt.Println("----- TestLoggerWith -----")
+	logger, err := NewLogger(&Config{LogLevelGlobal: LOG_LEVEL_DEBUG, LogLineFormat: "%level %msg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger.stdLogger.SetOutput(&buf)
+	logger.stdLogger.SetFlags(0)
+
+	raftLogger := logger.With("component", "raft", "node", 3)
+	nodeLogger := raftLogger.With(String("term", "7"))
+	otherLogger := raftLogger.With("role", "leader")
+	logger.Info("父Logger")
+	raftLogger.Infow("子Logger", "index", 100)
+	nodeLogger.Warn("孙Logger")
+	otherLogger.Error("另一个孙Logger")
+	raftLogger.Debugf("子Logger: %d", 1)
+	if logger.With() != logger {
+		t.Fatal("没有字段时With应该返回Logger本身")
+	}
+	if len(raftLogger.fields) != 2 {
+		t.Fatal("派生子Logger不应修改父Logger的绑定字段")
+	}
+
+	fmt.Print(buf.String())
+	expected := []string{
+		"[ INFO] 父Logger",
+		"[ INFO] 子Logger component=raft node=3 index=100",
+		"[ WARN] 孙Logger component=raft node=3 term=7",
+		"[ERROR] 另一个孙Logger component=raft node=3 role=leader",
+		"[DEBUG] 子Logger: 1 component=raft node=3",
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("日志行数不符合预期: %d", len(lines))
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("第%d行日志不符合预期: %s", i+1, lines[i])
+		}
+	}
+}
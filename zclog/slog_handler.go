@@ -0,0 +1,158 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+/*
+zclog/slog_handler.go 基于zclog的log/slog Handler实现，使通过slog输出的日志也经过zclog的日志级别控制、异步输出与文件滚动
+*/
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slog扩展日志级别，对应zclog的PANIC与FATAL
+//
+//	通过SlogHandler输出这两个级别的日志时，只按对应级别输出日志，不会终止goroutine或程序
+const (
+	// SlogLevelPanic 对应 LOG_LEVEL_PANIC
+	SlogLevelPanic = slog.Level(12)
+	// SlogLevelFatal 对应 LOG_LEVEL_FATAL
+	SlogLevelFatal = slog.Level(16)
+)
+
+// SlogHandler 基于zclog的slog.Handler实现
+//
+//	slog日志级别映射: 低于Info为DEBUG，[Info,Warn)为INFO，[Warn,Error)为WARNING，
+//	[Error,SlogLevelPanic)为ERROR，[SlogLevelPanic,SlogLevelFatal)为PANIC，SlogLevelFatal及以上为FATAL；
+//	调用方信息从slog.Record的PC获取，因此指定函数的日志级别控制同样生效；
+//	slog的属性转换为zclog的结构化字段，group内的属性以"group.key"作为字段名。
+type SlogHandler struct {
+	logger *Logger
+	// WithAttrs绑定的字段，字段名已加上group前缀
+	fields []Field
+	// 当前group前缀，如"request.header."
+	groupPrefix string
+}
+
+// NewSlogHandler 创建基于指定Logger的slog.Handler，logger为nil时使用默认Logger
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	if logger == nil {
+		logger = defaultLogger
+	}
+	return &SlogHandler{logger: logger}
+}
+
+// 将slog日志级别映射为zclog日志级别
+func slogLevelToLogLevel(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return LOG_LEVEL_DEBUG
+	case level < slog.LevelWarn:
+		return LOG_LEVEL_INFO
+	case level < slog.LevelError:
+		return LOG_LEVEL_WARNING
+	case level < SlogLevelPanic:
+		return LOG_LEVEL_ERROR
+	case level < SlogLevelFatal:
+		return LOG_LEVEL_PANIC
+	default:
+		return LOG_LEVEL_FATAL
+	}
+}
+
+// Enabled 判断指定级别的日志是否可能需要输出
+//
+//	此时还不知道调用方函数，只要全局日志级别或任意一个指定函数的日志级别允许输出就返回true，
+//	在Handle中再根据调用方函数做准确判断。
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	msgLogLevel := slogLevelToLogLevel(level)
+	if msgLogLevel >= LOG_LEVEL_PANIC || h.logger.GetLevel() <= msgLogLevel {
+		return true
+	}
+	funcLevel := minFuncLogLevel()
+	return funcLevel > 0 && funcLevel <= msgLogLevel
+}
+
+// Handle 输出slog日志记录
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	msgLogLevel := slogLevelToLogLevel(r.Level)
+	// PANIC与FATAL总是输出，与zclog的Panic/Fatal接口一致
+	if msgLogLevel < LOG_LEVEL_PANIC && !h.logger.levelEnabled(r.PC, msgLogLevel) {
+		return nil
+	}
+	var file, myFunc string
+	var line int
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file, line, myFunc = frame.File, frame.Line, frame.Function
+	}
+	var fields []Field
+	if r.NumAttrs() > 0 {
+		fields = make([]Field, 0, len(h.fields)+r.NumAttrs())
+		fields = append(fields, h.fields...)
+		r.Attrs(func(attr slog.Attr) bool {
+			fields = appendSlogAttr(fields, h.groupPrefix, attr)
+			return true
+		})
+	} else {
+		fields = h.fields
+	}
+	pushMsg := h.logger.newLogMsg(msgLogLevel, file, line, myFunc, r.Message, ctx, false, nil, fields)
+	if !r.Time.IsZero() {
+		pushMsg.pushTime = r.Time
+	}
+	h.logger.dispatchLogMsg(pushMsg)
+	return nil
+}
+
+// WithAttrs 返回绑定了指定属性的Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make([]Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.groupPrefix, attr)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, groupPrefix: h.groupPrefix}
+}
+
+// WithGroup 返回指定group的Handler，之后的属性都归属于该group
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, fields: h.fields, groupPrefix: h.groupPrefix + name + "."}
+}
+
+// 将slog属性转换为字段追加到fields
+//
+//	按slog.Handler的约定: 忽略空属性；忽略没有属性的group；key为空的group将其属性直接展开到当前层级。
+func appendSlogAttr(fields []Field, prefix string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, groupPrefix, groupAttr)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + attr.Key, Value: attr.Value.Any()})
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright (c) 2022 zhaochun
+   gitee.com/zhaochuninhefei/zcgolog is licensed under Mulan PSL v2.
+   You can use this software according to the terms and conditions of the Mulan PSL v2.
+   You may obtain a copy of Mulan PSL v2 at:
+            http://license.coscl.org.cn/MulanPSL2
+   THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND, EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT, MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+   See the Mulan PSL v2 for more details.
+*/
+
+package zclog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	fmt.Println("----- TestSlogHandler -----")
+	logger, err := NewLogger(&Config{LogLevelGlobal: LOG_LEVEL_INFO, LogLineFormat: "%level %callFunc %msg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	logger.stdLogger.SetOutput(&buf)
+	logger.stdLogger.SetFlags(0)
+
+	slogger := slog.New(NewSlogHandler(logger))
+	slogger.Debug("DEBUG日志不输出")
+	slogger.Info("slog日志", "user", 1001, slog.Group("req", "method", "GET", "path", "/api"))
+	reqLogger := slogger.With("component", "api").WithGroup("resp")
+	reqLogger.Warn("分组", "status", 500, slog.Group("empty"))
+	reqLogger.Error("嵌套分组", slog.Group("", "inline", true), slog.Group("header", "size", 3))
+	slogger.Log(context.Background(), SlogLevelPanic, "PANIC级别不会终止")
+	slogger.Log(context.Background(), SlogLevelFatal, "FATAL级别不会终止")
+	if slogger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("全局日志级别为INFO时DEBUG不应启用")
+	}
+
+	// 指定函数的日志级别控制同样作用于slog日志
+	caller := "gitee.com/zhaochuninhefei/zcgolog/zclog.TestSlogHandler"
+	setFuncLogLevel(caller, LOG_LEVEL_DEBUG)
+	defer setFuncLogLevel(caller, 0)
+	if !slogger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("存在指定DEBUG级别的函数时DEBUG应该启用")
+	}
+	slogger.Debug("指定函数级别后输出DEBUG")
+
+	fmt.Print(buf.String())
+	expected := []string{
+		"[ INFO] " + caller + " slog日志 user=1001 req.method=GET req.path=/api",
+		"[ WARN] " + caller + " 分组 component=api resp.status=500",
+		"[ERROR] " + caller + " 嵌套分组 component=api resp.inline=true resp.header.size=3",
+		"[PANIC] " + caller + " PANIC级别不会终止",
+		"[FATAL] " + caller + " FATAL级别不会终止",
+		"[DEBUG] " + caller + " 指定函数级别后输出DEBUG",
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("日志行数不符合预期: %d", len(lines))
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("第%d行日志不符合预期: %s", i+1, lines[i])
+		}
+	}
+}
+
+func TestSlogLevelToLogLevel(t *testing.T) {
+	fmt.Println("----- TestSlogLevelToLogLevel -----")
+	cases := map[slog.Level]int{
+		slog.LevelDebug - 4:  LOG_LEVEL_DEBUG,
+		slog.LevelDebug:      LOG_LEVEL_DEBUG,
+		slog.LevelInfo:       LOG_LEVEL_INFO,
+		slog.LevelInfo + 2:   LOG_LEVEL_INFO,
+		slog.LevelWarn:       LOG_LEVEL_WARNING,
+		slog.LevelError:      LOG_LEVEL_ERROR,
+		SlogLevelPanic - 1:   LOG_LEVEL_ERROR,
+		SlogLevelPanic:       LOG_LEVEL_PANIC,
+		SlogLevelFatal:       LOG_LEVEL_FATAL,
+		SlogLevelFatal + 100: LOG_LEVEL_FATAL,
+	}
+	for slogLevel, logLevel := range cases {
+		if got := slogLevelToLogLevel(slogLevel); got != logLevel {
+			t.Fatalf("slog级别 %v 应该映射为 %s, 实际为 %s", slogLevel, GetLogLevelStrByInt(logLevel), GetLogLevelStrByInt(got))
+		}
+	}
+}
@@ -85,19 +85,55 @@ func homeWindows() (string, error) {
 //	返回 1 代表检查OK, error为nil;
 //	返回 2 代表检查OK但LogFileDir为空，error为nil;
 //	返回 -9 代表检查失败，error非nil;
+//	检查前先为未设置(零值)且有默认值的配置项填充默认值。
 func CheckConfig(logConfig *Config) (int, error) {
 	if logConfig == nil {
 		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志配置不可为空")
 	}
+	fillConfigDefaults(logConfig)
 	if logConfig.LogFileNamePrefix == "" {
 		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志文件名前缀不可为空")
 	}
 	if logConfig.LogFileMaxSizeM <= 0 {
 		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志文件Size上限必须大于0")
 	}
+	if logConfig.LogFileRotateInterval < LOG_ROTATE_DAILY || logConfig.LogFileRotateInterval >= log_rotate_max {
+		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志文件滚动周期不能超出有效范围")
+	}
+	if logConfig.LogFileRotateOffsetMinutes < 0 || logConfig.LogFileRotateOffsetMinutes >= rotateIntervalMinutes(logConfig.LogFileRotateInterval) {
+		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志文件滚动时间偏移必须大于等于0且小于滚动周期: %d 分钟", rotateIntervalMinutes(logConfig.LogFileRotateInterval))
+	}
+	if _, err := parseLogFileNamePattern(logConfig); err != nil {
+		return CONFIG_CHECK_RESULT_NG, err
+	}
+	if err := checkLogFilePermConfig(logConfig); err != nil {
+		return CONFIG_CHECK_RESULT_NG, err
+	}
+	if logConfig.LogFileBufferSizeK < 0 || logConfig.LogFileFlushIntervalMilliSec < 0 {
+		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志文件写入缓冲区大小与刷新间隔不能小于0")
+	}
+	if logConfig.LogFileCompression != "" {
+		if _, ok := getCompressor(logConfig.LogFileCompression); !ok {
+			return CONFIG_CHECK_RESULT_NG, fmt.Errorf("不支持的日志文件压缩方式: %s", logConfig.LogFileCompression)
+		}
+	}
+	if logConfig.LogFileMaxAgeDays < 0 || logConfig.LogFileMaxCount < 0 || logConfig.LogFileMaxTotalSizeM < 0 {
+		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("日志文件保留策略配置不能小于0")
+	}
+	if err := checkErrorFileConfig(logConfig); err != nil {
+		return CONFIG_CHECK_RESULT_NG, err
+	}
+	if err := checkSinkConfigs(logConfig); err != nil {
+		return CONFIG_CHECK_RESULT_NG, err
+	}
 	if logConfig.LogLevelGlobal < LOG_LEVEL_DEBUG || logConfig.LogLevelGlobal >= log_level_max {
 		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("全局日志级别不能超出有效范围")
 	}
+	if logConfig.LogLineFormat != "" {
+		if err := CheckLogLineFormat(logConfig.LogLineFormat); err != nil {
+			return CONFIG_CHECK_RESULT_NG, err
+		}
+	}
 	// LogFileDir在本地模式下可以为空，服务器模式下不可为空
 	if logConfig.LogMod == LOG_MODE_SERVER && logConfig.LogFileDir == "" {
 		return CONFIG_CHECK_RESULT_NG, fmt.Errorf("服务器模式下日志目录不可为空")
@@ -107,3 +143,34 @@ func CheckConfig(logConfig *Config) (int, error) {
 	}
 	return CONFIG_CHECK_RESULT_OK, nil
 }
+
+// 为未设置(零值)的配置项填充默认值
+//
+//	调用方自行构造的配置(如直接调用GetLogFilePathAndYMDToday)可能没有设置后来新增的配置项，
+//	这些配置项为零值时按默认值处理，与InitLogger、NewLogger的默认值相同。
+func fillConfigDefaults(logConfig *Config) {
+	if logConfig.LogFileRotateInterval == 0 {
+		logConfig.LogFileRotateInterval = LOG_ROTATE_DAILY
+	}
+	if logConfig.LogFileNamePattern == "" {
+		logConfig.LogFileNamePattern = LOG_FILE_NAME_PATTERN_DEFAULT
+	}
+	if logConfig.LogFileSeqWidth == 0 {
+		logConfig.LogFileSeqWidth = 5
+	}
+	if logConfig.LogFileExt == "" {
+		logConfig.LogFileExt = ".log"
+	}
+	if logConfig.LogFileMode == 0 {
+		logConfig.LogFileMode = LOG_FILE_MODE_DEFAULT
+	}
+	if logConfig.LogDirMode == 0 {
+		logConfig.LogDirMode = LOG_DIR_MODE_DEFAULT
+	}
+	if logConfig.LogFileBufferSizeK == 0 {
+		logConfig.LogFileBufferSizeK = 256
+	}
+	if logConfig.LogFileFlushIntervalMilliSec == 0 {
+		logConfig.LogFileFlushIntervalMilliSec = 1000
+	}
+}